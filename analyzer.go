@@ -1,44 +1,207 @@
 package dl
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 )
 
 const BufLen = 512
 
+// Analyzer is a single stage of a ProcessingChain
 type Analyzer interface {
-	Process(<-chan Sample, chan<- Sample)
+	// Process reads samples from input and writes samples to output
+	// until input is closed.  Process must stop and return the context's
+	// error if the context is cancelled.  Process must not close output,
+	// the ProcessingChain closes output once Process returns
+	Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error
 }
 
+// Source produces the samples at the head of a ProcessingChain
+type Source interface {
+	// Read produces samples until the end of the input is reached or the
+	// context is cancelled.  The Output channel must be closed before Read
+	// returns
+	Read(ctx context.Context) error
+
+	// Output returns the channel that Samples can be read from
+	Output() <-chan Sample
+}
+
+// send writes a sample to the output channel unless the context is
+// cancelled first
+func send(ctx context.Context, output chan<- Sample, sample Sample) error {
+	select {
+	case output <- sample:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ProcessingChain runs a Source and a series of Analyzers, each in their
+// own go routine, with the output of each stage connected to the input of
+// the next.  The first stage to fail cancels all the other stages
 type ProcessingChain struct {
-	sync.WaitGroup
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	errs     []error
 	channels []<-chan Sample
 }
 
-func NewProcessingChain(input <-chan Sample) *ProcessingChain {
-	return &ProcessingChain{
-		channels: []<-chan Sample{input},
+// NewProcessingChain starts reading from the source and returns a
+// ProcessingChain that analyzers can be appended to
+func NewProcessingChain(ctx context.Context, source Source) *ProcessingChain {
+	ctx, cancel := context.WithCancel(ctx)
+	output := make(chan Sample, BufLen)
+	pc := &ProcessingChain{
+		ctx:      ctx,
+		cancel:   cancel,
+		channels: []<-chan Sample{output},
+	}
+
+	pc.run(func() error { return source.Read(ctx) }, nil)
+	pc.run(func() error {
+		return forward(ctx, source.Output(), output)
+	}, func() {
+		close(output)
+	})
+	return pc
+}
+
+// forward copies samples from input to output until input is closed or the
+// context is cancelled.  A Source that panics, or returns, without closing
+// its output therefore cannot block the rest of the chain
+func forward(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	for {
+		select {
+		case sample, ok := <-input:
+			if !ok {
+				return nil
+			}
+
+			if err := send(ctx, output, sample); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 }
 
+// run executes fn in a new go routine.  Panics are recovered and, along
+// with any returned error, cause the rest of the chain to be cancelled.
+// The cleanup function, if given, is called after the chain has been
+// cancelled
+func (pc *ProcessingChain) run(fn func() error, cleanup func()) {
+	pc.wg.Add(1)
+	go func() {
+		defer pc.wg.Done()
+		if err := recoverCall(fn); err != nil {
+			pc.mu.Lock()
+			pc.errs = append(pc.errs, err)
+			pc.mu.Unlock()
+			pc.cancel()
+		}
+
+		if cleanup != nil {
+			cleanup()
+		}
+	}()
+}
+
+func recoverCall(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// Append adds an analyzer to the end of the chain.  The analyzer's input
+// is the output of the previous stage
 func (pc *ProcessingChain) Append(analyzer Analyzer) *ProcessingChain {
 	input := pc.channels[len(pc.channels)-1]
 	output := make(chan Sample, BufLen)
 	pc.channels = append(pc.channels, output)
-	pc.WaitGroup.Add(1)
-	go func() {
-		analyzer.Process(input, output)
-		pc.WaitGroup.Done()
-	}()
+	pc.run(func() error {
+		return analyzer.Process(pc.ctx, input, output)
+	}, func() {
+		// drain anything the analyzer didn't read so that upstream
+		// stages are never blocked
+		for range input {
+		}
+		close(output)
+	})
 	return pc
 }
 
+// Output returns the output channel of the last stage in the chain.  The
+// output must not be read while Wait is running
+func (pc *ProcessingChain) Output() <-chan Sample {
+	return pc.channels[len(pc.channels)-1]
+}
+
+// Wait waits for every stage in the chain to complete and returns the
+// errors that caused the chain to stop.  Any samples remaining on the
+// output of the last stage are discarded, so callers consuming Output
+// must do so before calling Wait.  Output must not be read by another
+// go routine while Wait is running, since Wait would compete with it for
+// the remaining samples
+func (pc *ProcessingChain) Wait() error {
+	for range pc.Output() {
+	}
+	pc.wg.Wait()
+	pc.cancel()
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	// stages that stopped because another stage failed report the
+	// cancellation, which is not interesting when there is a real cause
+	var errs []error
+	for _, err := range pc.errs {
+		if !errors.Is(err, context.Canceled) {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 && len(pc.errs) > 0 {
+		return pc.errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// ChannelCounter counts the number of messages received on each data
+// channel.  All input samples are passed to the output
+type ChannelCounter map[Channel]int
+
+// Process counts every input message.  This should usually be run in a
+// go routine
+func (cc ChannelCounter) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	for sample := range input {
+		if message, ok := sample.(*Message); ok {
+			cc[message.Channel]++
+		}
+
+		if err := send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrintAnalyzer prints every sample to stdout
 type PrintAnalyzer struct{}
 
-func (pa *PrintAnalyzer) Process(input <-chan Sample, output chan<- Sample) {
+// Process prints every input sample.  Samples are not passed to the output
+func (pa *PrintAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
 	for sample := range input {
 		fmt.Printf("%v\n", sample)
 	}
-	close(output)
+	return nil
 }
@@ -0,0 +1,238 @@
+package dl
+
+import (
+	"context"
+)
+
+// SectorSplit is the time taken to complete one sector
+type SectorSplit struct {
+	// Sector is the sector number
+	Sector int
+
+	// Lap is the lap the sector was completed in
+	Lap int
+
+	// Time is the time taken to complete the sector
+	Time TimeOffset
+
+	// Delta is the difference between Time and the best time for the
+	// sector.  Positive values are slower than the best time
+	Delta TimeOffset
+}
+
+// LapSplits are the sector times of a complete lap
+type LapSplits struct {
+	// Lap is the lap number
+	Lap int
+
+	// Time is the lap time
+	Time TimeOffset
+
+	// Delta is the difference between Time and the best lap time
+	Delta TimeOffset
+
+	// Sectors are the sector times of the lap, in the order they were
+	// driven
+	Sectors []SectorSplit
+}
+
+// LapAnalysis compares the laps and sectors of a session.  It is emitted
+// by the BestLapAnalyzer once the end of the input has been reached
+type LapAnalysis struct {
+	// BestLap is the number of the fastest lap
+	BestLap int
+
+	// BestLapTime is the time of the fastest lap
+	BestLapTime TimeOffset
+
+	// BestSectors are the fastest times for each sector, in the order the
+	// sectors are driven
+	BestSectors []SectorSplit
+
+	// TheoreticalBest is the sum of the best sector times
+	TheoreticalBest TimeOffset
+
+	// RollingBest is the fastest time to complete one of each sector
+	// consecutively, regardless of where the window started
+	RollingBest TimeOffset
+
+	// RollingBestLap is the lap in which the rolling best window started
+	RollingBestLap int
+
+	// RollingBestSector is the sector that started the rolling best window
+	RollingBestSector int
+
+	// Laps are the splits of every complete lap
+	Laps []LapSplits
+}
+
+// Type returns the Sample Type, in this case "LapAnalysis"
+func (*LapAnalysis) Type() string { return "LapAnalysis" }
+
+// BestLapAnalyzer computes the theoretical best lap, the rolling best lap
+// and the sector deltas of every lap.  Sector times are taken from the
+// Sector and Lap samples produced by a SectorAnalyzer.  If the input
+// contains no Sectors the SectorTime samples reported by the data logger
+// are used instead.  The logger reports each sector time against the marker
+// that closed the sector, so the lap markers are needed to group these times
+// into laps.  In that case, a lap is complete when the start/finish line
+// closes it and every sector of the lap was reported.  The sectors before
+// the first crossing of the start/finish line are ignored.  A single
+// LapAnalysis is emitted once the input is closed.  All input samples are
+// passed to the output
+type BestLapAnalyzer struct {
+	sectorInfo *SectorInfo
+}
+
+// NewBestLapAnalyzer returns a BestLapAnalyzer that will use the LapMarker
+// samples found in the input stream to group the logger's sector times
+func NewBestLapAnalyzer() *BestLapAnalyzer { return &BestLapAnalyzer{} }
+
+// SectorInfo sets the lap markers used to group the logger's sector times.
+// When sector information is supplied any LapMarker samples in the input
+// stream are ignored
+func (bla *BestLapAnalyzer) SectorInfo(sectorInfo *SectorInfo) *BestLapAnalyzer {
+	bla.sectorInfo = sectorInfo
+	return bla
+}
+
+// loggerSplits groups the sector times reported by the logger into laps.
+// The times are numbered by the marker that opened the sector, the same as
+// the Sectors of a SectorAnalyzer.  Times reported for unknown markers are
+// ignored
+func loggerSplits(sectorTimes []*SectorTime, markers []LapMarker) (splits []SectorSplit, complete map[int]bool) {
+	complete = make(map[int]bool)
+	if len(markers) == 0 {
+		return nil, complete
+	}
+
+	// markers are crossed in order, so a sector is opened by the marker
+	// before the one that closes it
+	opened := make(map[int]int)
+	for i, marker := range markers {
+		opened[marker.Marker] = markers[(i+len(markers)-1)%len(markers)].Marker
+	}
+	finish := markers[0].Marker
+
+	lap := 0
+	seen := make(map[int]bool)
+	for _, sectorTime := range sectorTimes {
+		sector, found := opened[sectorTime.Sector]
+		if !found {
+			continue
+		}
+
+		if lap > 0 {
+			splits = append(splits, SectorSplit{Sector: sector, Lap: lap, Time: sectorTime.Time})
+			seen[sector] = true
+		}
+
+		if sectorTime.Sector == finish {
+			if lap > 0 && len(seen) == len(markers) {
+				complete[lap] = true
+			}
+			lap++
+			seen = make(map[int]bool)
+		}
+	}
+	return splits, complete
+}
+
+// analyzeLaps computes the lap analysis for the sector splits, which must
+// be in the order they were driven
+func analyzeLaps(splits []SectorSplit, complete map[int]bool) *LapAnalysis {
+	analysis := &LapAnalysis{}
+	best := make(map[int]SectorSplit)
+	for _, split := range splits {
+		if b, found := best[split.Sector]; !found || split.Time < b.Time {
+			best[split.Sector] = split
+		}
+	}
+
+	for i := 0; i < len(splits); {
+		lap := LapSplits{Lap: splits[i].Lap}
+		for ; i < len(splits) && splits[i].Lap == lap.Lap; i++ {
+			split := splits[i]
+			split.Delta = split.Time - best[split.Sector].Time
+			lap.Time += split.Time
+			lap.Sectors = append(lap.Sectors, split)
+		}
+
+		if complete[lap.Lap] {
+			if analysis.BestLap == 0 || lap.Time < analysis.BestLapTime {
+				analysis.BestLap = lap.Lap
+				analysis.BestLapTime = lap.Time
+			}
+			analysis.Laps = append(analysis.Laps, lap)
+		}
+	}
+
+	for i := range analysis.Laps {
+		analysis.Laps[i].Delta = analysis.Laps[i].Time - analysis.BestLapTime
+	}
+
+	// the sector order is taken from the first lap that includes every sector
+	for _, lap := range analysis.Laps {
+		if len(lap.Sectors) == len(best) {
+			for _, split := range lap.Sectors {
+				analysis.BestSectors = append(analysis.BestSectors, best[split.Sector])
+				analysis.TheoreticalBest += best[split.Sector].Time
+			}
+			break
+		}
+	}
+
+	n := len(best)
+	for i := 0; n > 0 && i+n <= len(splits); i++ {
+		seen := make(map[int]bool)
+		var window TimeOffset
+		for _, split := range splits[i : i+n] {
+			seen[split.Sector] = true
+			window += split.Time
+		}
+
+		if len(seen) == n && (analysis.RollingBest == 0 || window < analysis.RollingBest) {
+			analysis.RollingBest = window
+			analysis.RollingBestLap = splits[i].Lap
+			analysis.RollingBestSector = splits[i].Sector
+		}
+	}
+	return analysis
+}
+
+// Process will start the best lap loop.  This should usually be run in
+// a go routine
+func (bla *BestLapAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	var splits []SectorSplit
+	var sectorTimes []*SectorTime
+	complete := make(map[int]bool)
+
+	sectorInfo := bla.sectorInfo
+	if sectorInfo == nil {
+		sectorInfo = &SectorInfo{}
+	}
+
+	for sample := range input {
+		switch v := sample.(type) {
+		case *LapMarker:
+			if bla.sectorInfo == nil {
+				sectorInfo.AddMarker(v)
+			}
+		case *Sector:
+			splits = append(splits, SectorSplit{Sector: v.Number, Lap: v.Lap, Time: v.Elapsed()})
+		case *Lap:
+			complete[v.Number] = true
+		case *SectorTime:
+			sectorTimes = append(sectorTimes, v)
+		}
+
+		if err := send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+
+	if len(splits) == 0 {
+		splits, complete = loggerSplits(sectorTimes, sectorInfo.Markers())
+	}
+	return send(ctx, output, analyzeLaps(splits, complete))
+}
@@ -0,0 +1,180 @@
+package dl
+
+import (
+	"reflect"
+	"testing"
+)
+
+// lapSplits returns the splits of a lap, taking the time of each of the
+// sectors in turn
+func lapSplits(lap int, sectors []int, times ...TimeOffset) []SectorSplit {
+	var splits []SectorSplit
+	for i, time := range times {
+		splits = append(splits, SectorSplit{Sector: sectors[i], Lap: lap, Time: time})
+	}
+	return splits
+}
+
+func TestAnalyzeLaps(t *testing.T) {
+	all := []int{1, 2, 3}
+	tests := []struct {
+		name              string
+		splits            [][]SectorSplit
+		complete          map[int]bool
+		bestLap           int
+		bestLapTime       TimeOffset
+		lapTimes          []TimeOffset
+		lapDeltas         []TimeOffset
+		bestSectors       []TimeOffset
+		theoreticalBest   TimeOffset
+		rollingBest       TimeOffset
+		rollingBestLap    int
+		rollingBestSector int
+	}{
+		{
+			name: "complete laps",
+			splits: [][]SectorSplit{
+				lapSplits(1, all, 30000, 40000, 35000),
+				lapSplits(2, all, 29000, 41000, 32000),
+				lapSplits(3, all, 31000, 38000, 36000),
+			},
+			complete:          map[int]bool{1: true, 2: true, 3: true},
+			bestLap:           2,
+			bestLapTime:       102000,
+			lapTimes:          []TimeOffset{105000, 102000, 105000},
+			lapDeltas:         []TimeOffset{3000, 0, 3000},
+			bestSectors:       []TimeOffset{29000, 38000, 32000},
+			theoreticalBest:   99000,
+			rollingBest:       101000,
+			rollingBestLap:    2,
+			rollingBestSector: 3,
+		},
+		{
+			name: "missing sector",
+			splits: [][]SectorSplit{
+				lapSplits(1, all, 30000, 40000, 35000),
+				lapSplits(2, []int{1, 3}, 28000, 33000),
+				lapSplits(3, all, 31000, 39000, 34000),
+			},
+			complete:          map[int]bool{1: true, 3: true},
+			bestLap:           3,
+			bestLapTime:       104000,
+			lapTimes:          []TimeOffset{105000, 104000},
+			lapDeltas:         []TimeOffset{1000, 0},
+			bestSectors:       []TimeOffset{28000, 39000, 33000},
+			theoreticalBest:   100000,
+			rollingBest:       103000,
+			rollingBestLap:    1,
+			rollingBestSector: 2,
+		},
+	}
+
+	for _, test := range tests {
+		var splits []SectorSplit
+		for _, lap := range test.splits {
+			splits = append(splits, lap...)
+		}
+
+		analysis := analyzeLaps(splits, test.complete)
+		if analysis.BestLap != test.bestLap || analysis.BestLapTime != test.bestLapTime {
+			t.Errorf("%s: expected best lap %d in %v got %d in %v", test.name, test.bestLap, test.bestLapTime, analysis.BestLap, analysis.BestLapTime)
+		}
+
+		var lapTimes, lapDeltas []TimeOffset
+		for _, lap := range analysis.Laps {
+			lapTimes = append(lapTimes, lap.Time)
+			lapDeltas = append(lapDeltas, lap.Delta)
+		}
+
+		if !reflect.DeepEqual(lapTimes, test.lapTimes) || !reflect.DeepEqual(lapDeltas, test.lapDeltas) {
+			t.Errorf("%s: expected lap times %v deltas %v got %v %v", test.name, test.lapTimes, test.lapDeltas, lapTimes, lapDeltas)
+		}
+
+		var bestSectors []TimeOffset
+		for _, split := range analysis.BestSectors {
+			bestSectors = append(bestSectors, split.Time)
+		}
+
+		if !reflect.DeepEqual(bestSectors, test.bestSectors) || analysis.TheoreticalBest != test.theoreticalBest {
+			t.Errorf("%s: expected best sectors %v (%v) got %v (%v)", test.name, test.bestSectors, test.theoreticalBest, bestSectors, analysis.TheoreticalBest)
+		}
+
+		if analysis.RollingBest != test.rollingBest || analysis.RollingBestLap != test.rollingBestLap || analysis.RollingBestSector != test.rollingBestSector {
+			t.Errorf("%s: expected rolling best %v from lap %d sector %d got %v from lap %d sector %d", test.name, test.rollingBest, test.rollingBestLap, test.rollingBestSector, analysis.RollingBest, analysis.RollingBestLap, analysis.RollingBestSector)
+		}
+
+		for _, lap := range analysis.Laps {
+			for _, split := range lap.Sectors {
+				if expected := split.Time - test.bestSectors[split.Sector-1]; split.Delta != expected {
+					t.Errorf("%s: lap %d sector %d expected delta %v got %v", test.name, lap.Lap, split.Sector, expected, split.Delta)
+				}
+			}
+		}
+	}
+}
+
+func TestBestLapAnalyzerLoggerTimes(t *testing.T) {
+	// the logger reports each time against the marker that closed the
+	// sector, so marker 1 closes the sector opened by marker 3
+	samples := []Sample{
+		&LapMarker{Marker: 1},
+		&LapMarker{Marker: 2},
+		&LapMarker{Marker: 3},
+		&SectorTime{Sector: 3, Time: 12000},
+		&SectorTime{Sector: 1, Time: 20000},
+		&SectorTime{Sector: 2, Time: 30000},
+		&SectorTime{Sector: 3, Time: 40000},
+		&SectorTime{Sector: 7, Time: 1000},
+		&SectorTime{Sector: 1, Time: 35000},
+		&SectorTime{Sector: 2, Time: 28000},
+		&SectorTime{Sector: 1, Time: 33000},
+		&SectorTime{Sector: 2, Time: 31000},
+		&SectorTime{Sector: 3, Time: 39000},
+		&SectorTime{Sector: 1, Time: 34000},
+	}
+
+	var analysis *LapAnalysis
+	for _, sample := range runAnalyzers(t, samples, NewBestLapAnalyzer()) {
+		if v, ok := sample.(*LapAnalysis); ok {
+			analysis = v
+		}
+	}
+
+	if analysis == nil {
+		t.Fatalf("expected a LapAnalysis")
+	}
+
+	var laps []int
+	for _, lap := range analysis.Laps {
+		laps = append(laps, lap.Lap)
+	}
+
+	if !reflect.DeepEqual(laps, []int{1, 3}) || analysis.BestLap != 3 || analysis.BestLapTime != 104000 {
+		t.Errorf("expected laps [1 3] with lap 3 best in 104000ms got %v with lap %d best in %v", laps, analysis.BestLap, analysis.BestLapTime)
+	}
+
+	var sectors []int
+	for _, split := range analysis.Laps[0].Sectors {
+		sectors = append(sectors, split.Sector)
+	}
+
+	if !reflect.DeepEqual(sectors, []int{1, 2, 3}) {
+		t.Errorf("expected sectors numbered by their opening marker [1 2 3] got %v", sectors)
+	}
+
+	if analysis.TheoreticalBest != 100000 {
+		t.Errorf("expected a theoretical best of 100000ms got %v", analysis.TheoreticalBest)
+	}
+
+	// without lap markers the logger's times can not be grouped into laps
+	analysis = nil
+	for _, sample := range runAnalyzers(t, samples[3:], NewBestLapAnalyzer()) {
+		if v, ok := sample.(*LapAnalysis); ok {
+			analysis = v
+		}
+	}
+
+	if analysis == nil || len(analysis.Laps) != 0 {
+		t.Errorf("expected no laps without lap markers got %+v", analysis)
+	}
+}
@@ -0,0 +1,236 @@
+package dl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Measurement is a named value calculated from the logged data
+type Measurement struct {
+	// Value is the calculated value
+	Value float64
+
+	// Unit is the unit the value is measured in
+	Unit string
+}
+
+// Format satisfies interface fmt.Formatter
+func (m Measurement) Format(f fmt.State, c rune) { formatUnit(f, c, m.Unit, m, m.Value) }
+
+// Conversion converts a raw input reading into engineering units
+type Conversion interface {
+	// Convert returns the converted value of the raw input
+	Convert(raw float64) float64
+}
+
+// Linear is a Conversion that scales and offsets the raw input
+type Linear struct {
+	Scale  float64 `json:"scale"`
+	Offset float64 `json:"offset"`
+}
+
+// Convert returns raw * Scale + Offset
+func (l *Linear) Convert(raw float64) float64 { return raw*l.Scale + l.Offset }
+
+// Polynomial is a Conversion using the polynomial with the given
+// coefficients, starting with the constant term
+type Polynomial []float64
+
+// Convert evaluates the polynomial for the raw input
+func (p Polynomial) Convert(raw float64) float64 {
+	value := 0.0
+	for i := len(p) - 1; i >= 0; i-- {
+		value = value*raw + p[i]
+	}
+	return value
+}
+
+// LookupTable is a Conversion that linearly interpolates between pairs of
+// raw input and converted values.  The raw inputs must be strictly
+// increasing.  Inputs outside of the table are clamped to the first or last
+// value
+type LookupTable [][2]float64
+
+// Convert looks up the raw input in the table
+func (lt LookupTable) Convert(raw float64) float64 {
+	i := sort.Search(len(lt), func(i int) bool { return lt[i][0] >= raw })
+	if i == 0 {
+		return lt[0][1]
+	} else if i == len(lt) {
+		return lt[len(lt)-1][1]
+	}
+
+	x0, y0 := lt[i-1][0], lt[i-1][1]
+	x1, y1 := lt[i][0], lt[i][1]
+	return y0 + (y1-y0)*(raw-x0)/(x1-x0)
+}
+
+// Sensor describes the sensor connected to an analog or frequency input of
+// the data logger.  Analog inputs are converted from millivolts and frequency
+// inputs from hertz
+type Sensor struct {
+	// Channel is the data channel the sensor is connected to
+	Channel Channel
+
+	// Name is the name of the calibrated measurement
+	Name string
+
+	// Unit is the unit of the calibrated measurement
+	Unit string
+
+	// Conversion converts the raw input to the calibrated measurement
+	Conversion Conversion
+}
+
+// UnmarshalJSON parses a sensor definition.  Exactly one of the "linear",
+// "polynomial" or "table" conversions must be given.  A ParseError is
+// returned if the raw inputs of a lookup table are not strictly increasing
+func (sensor *Sensor) UnmarshalJSON(data []byte) error {
+	var definition struct {
+		Channel    Channel     `json:"channel"`
+		Name       string      `json:"name"`
+		Unit       string      `json:"unit"`
+		Linear     *Linear     `json:"linear"`
+		Polynomial Polynomial  `json:"polynomial"`
+		Table      LookupTable `json:"table"`
+	}
+
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return err
+	}
+
+	var conversions []Conversion
+	if definition.Linear != nil {
+		conversions = append(conversions, definition.Linear)
+	}
+
+	if definition.Polynomial != nil {
+		conversions = append(conversions, definition.Polynomial)
+	}
+
+	if definition.Table != nil {
+		if len(definition.Table) == 0 {
+			return newParseError(fmt.Sprintf("Sensor %q has an empty lookup table", definition.Name))
+		}
+
+		for i := 1; i < len(definition.Table); i++ {
+			if definition.Table[i][0] <= definition.Table[i-1][0] {
+				return newParseError(fmt.Sprintf("Sensor %q lookup table inputs must be strictly increasing: %v follows %v", definition.Name, definition.Table[i][0], definition.Table[i-1][0]))
+			}
+		}
+		conversions = append(conversions, definition.Table)
+	}
+
+	if len(conversions) != 1 {
+		return newParseError(fmt.Sprintf("Sensor %q must have exactly one conversion", definition.Name))
+	}
+
+	sensor.Channel = definition.Channel
+	sensor.Name = definition.Name
+	sensor.Unit = definition.Unit
+	sensor.Conversion = conversions[0]
+	return nil
+}
+
+// rawValue returns the raw reading of the sensor's input in the epoch
+func (sensor *Sensor) rawValue(epoch *Epoch) (value float64, found bool) {
+	if voltage, found := epoch.AnalogInputs[sensor.Channel]; found {
+		return float64(voltage), true
+	}
+
+	if frequency, found := epoch.FrequencyInputs[sensor.Channel]; found {
+		return float64(frequency), true
+	}
+	return 0, false
+}
+
+// isCalibratable indicates whether the channel is an analog or frequency input
+func isCalibratable(channel Channel) bool {
+	return (AnalogChannel1 <= channel && channel <= AnalogChannel32) ||
+		(FrequencyChannel1 <= channel && channel <= FrequencyChannel5) ||
+		(ExtendedFrequencyChannel1 <= channel && channel <= ExtendedFrequencyChannel4)
+}
+
+// Calibration is the set of sensors connected to the data logger
+type Calibration struct {
+	Sensors []*Sensor `json:"sensors"`
+}
+
+// ReadCalibration reads a JSON calibration configuration from the reader.
+// Other formats, such as YAML, are not supported.  A ParseError is returned
+// if a sensor is missing, is not connected to an analog or frequency input,
+// or if two sensors have the same name
+func ReadCalibration(reader io.Reader) (*Calibration, error) {
+	calibration := &Calibration{}
+	if err := json.NewDecoder(reader).Decode(calibration); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for i, sensor := range calibration.Sensors {
+		if sensor == nil {
+			return nil, newParseError(fmt.Sprintf("Sensor %d is null", i+1))
+		}
+
+		if !isCalibratable(sensor.Channel) {
+			return nil, newParseError(fmt.Sprintf("Sensor %q: %v is not an analog or frequency input", sensor.Name, sensor.Channel))
+		}
+
+		if names[sensor.Name] {
+			return nil, newParseError(fmt.Sprintf("Duplicate sensor name %q", sensor.Name))
+		}
+		names[sensor.Name] = true
+	}
+	return calibration, nil
+}
+
+// LoadCalibration reads a JSON calibration configuration from the named
+// file.  Other formats, such as YAML, are not supported
+func LoadCalibration(filename string) (*Calibration, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadCalibration(file)
+}
+
+// CalibrationAnalyzer converts the raw analog and frequency inputs of each
+// Epoch into named Measurements according to the Calibration.  All input
+// samples are passed to the output
+type CalibrationAnalyzer struct {
+	calibration *Calibration
+}
+
+// NewCalibrationAnalyzer returns a CalibrationAnalyzer for the given
+// calibration
+func NewCalibrationAnalyzer(calibration *Calibration) *CalibrationAnalyzer {
+	return &CalibrationAnalyzer{calibration: calibration}
+}
+
+// Process will start the calibration loop.  This should usually be run in
+// a go routine
+func (ca *CalibrationAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	for sample := range input {
+		if epoch, ok := sample.(*Epoch); ok {
+			if epoch.Measurements == nil {
+				epoch.Measurements = make(map[string]Measurement)
+			}
+
+			for _, sensor := range ca.calibration.Sensors {
+				if raw, found := sensor.rawValue(epoch); found {
+					epoch.Measurements[sensor.Name] = Measurement{Value: sensor.Conversion.Convert(raw), Unit: sensor.Unit}
+				}
+			}
+		}
+
+		if err := send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package dl
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadCalibration(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		raw      float64
+		expected float64
+		parseErr bool
+	}{
+		{"linear", `{"sensors": [{"channel": "Analog 1", "name": "Brake", "linear": {"scale": 0.025, "offset": -12.5}}]}`, 1000, 12.5, false},
+		{"polynomial", `{"sensors": [{"channel": "Analog 1", "name": "Throttle", "polynomial": [-10, 0.024]}]}`, 1000, 14, false},
+		{"table", `{"sensors": [{"channel": "Analog 1", "name": "Oil", "table": [[450, 150], [1200, 100], [3900, 20]]}]}`, 825, 125, false},
+		{"table below", `{"sensors": [{"channel": "Analog 1", "name": "Oil", "table": [[450, 150], [1200, 100]]}]}`, 0, 150, false},
+		{"table above", `{"sensors": [{"channel": "Analog 1", "name": "Oil", "table": [[450, 150], [1200, 100]]}]}`, 5000, 100, false},
+		{"unsorted table", `{"sensors": [{"channel": "Analog 1", "name": "Oil", "table": [[1200, 100], [450, 150]]}]}`, 0, 0, true},
+		{"repeated input", `{"sensors": [{"channel": "Analog 1", "name": "Oil", "table": [[450, 150], [450, 100], [1200, 90]]}]}`, 0, 0, true},
+		{"empty table", `{"sensors": [{"channel": "Analog 1", "name": "Oil", "table": []}]}`, 0, 0, true},
+		{"no conversion", `{"sensors": [{"channel": "Analog 1", "name": "Oil"}]}`, 0, 0, true},
+		{"two conversions", `{"sensors": [{"channel": "Analog 1", "name": "Oil", "linear": {"scale": 1}, "polynomial": [1]}]}`, 0, 0, true},
+		{"not an input", `{"sensors": [{"channel": "Speed Data", "name": "Oil", "linear": {"scale": 1}}]}`, 0, 0, true},
+		{"null sensor", `{"sensors": [{"channel": "Analog 1", "name": "Oil", "linear": {"scale": 1}}, null]}`, 0, 0, true},
+		{"duplicate name", `{"sensors": [{"channel": "Analog 1", "name": "Oil", "linear": {"scale": 1}}, {"channel": "Analog 2", "name": "Oil", "linear": {"scale": 1}}]}`, 0, 0, true},
+	}
+
+	for _, test := range tests {
+		calibration, err := ReadCalibration(strings.NewReader(test.input))
+		var parseErr *ParseError
+		if test.parseErr {
+			if !errors.As(err, &parseErr) {
+				t.Errorf("%s: expected a ParseError got %v", test.name, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: ReadCalibration() returned %v", test.name, err)
+			continue
+		}
+
+		if value := calibration.Sensors[0].Conversion.Convert(test.raw); math.Abs(value-test.expected) > 1e-9 {
+			t.Errorf("%s: expected %v got %v", test.name, test.expected, value)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package dl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// brokenSource sends one sample and then fails without closing its output
+type brokenSource struct {
+	output chan Sample
+	panic  bool
+}
+
+func (bs *brokenSource) Read(ctx context.Context) error {
+	if err := send(ctx, bs.output, &Timestamp{}); err != nil {
+		return err
+	}
+
+	if bs.panic {
+		panic("source failed")
+	}
+	return errors.New("source failed")
+}
+
+func (bs *brokenSource) Output() <-chan Sample { return bs.output }
+
+// brokenAnalyzer fails after receiving its first sample
+type brokenAnalyzer struct{}
+
+func (*brokenAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	<-input
+	panic("analyzer failed")
+}
+
+// passAnalyzer passes every sample to the output without checking the
+// context while receiving
+type passAnalyzer struct{}
+
+func (*passAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	for sample := range input {
+		if err := send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestProcessingChainFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    Source
+		analyzers []Analyzer
+		expected  string
+	}{
+		{"source error", &brokenSource{output: make(chan Sample)}, []Analyzer{&passAnalyzer{}, &passAnalyzer{}}, "source failed"},
+		{"source panic", &brokenSource{output: make(chan Sample), panic: true}, []Analyzer{&passAnalyzer{}, &passAnalyzer{}}, "panic: source failed"},
+		{"analyzer panic", newSliceSource(&Timestamp{}, &Timestamp{}), []Analyzer{&passAnalyzer{}, &brokenAnalyzer{}, &passAnalyzer{}}, "panic: analyzer failed"},
+		{"source and analyzer panic", &brokenSource{output: make(chan Sample), panic: true}, []Analyzer{&brokenAnalyzer{}, &passAnalyzer{}}, "panic: analyzer failed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			done := make(chan error)
+			go func() {
+				chain := NewProcessingChain(context.Background(), test.source)
+				for _, analyzer := range test.analyzers {
+					chain.Append(analyzer)
+				}
+				done <- chain.Wait()
+			}()
+
+			select {
+			case err := <-done:
+				if err == nil || !strings.Contains(err.Error(), test.expected) {
+					t.Errorf("Expected error %q got %v", test.expected, err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Wait() did not return")
+			}
+		})
+	}
+}
+
+func TestProcessingChainCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// only the first sample is read before the chain is cancelled
+	chain := NewProcessingChain(ctx, newSliceSource(&Timestamp{}, &Timestamp{})).Append(&passAnalyzer{})
+	<-chain.Output()
+	cancel()
+
+	done := make(chan error)
+	go func() { done <- chain.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil && !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected nil or context.Canceled got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Wait() did not return")
+	}
+}
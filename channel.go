@@ -1,6 +1,13 @@
 //go:generate stringer -type=Channel -linecomment=true
 package dl
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Channel is the data channel that a data frame (in the serial stream) represents
 type Channel int
 
@@ -103,6 +110,51 @@ const (
 	VideoFrameIndexChannel            Channel = 104 // Video Frame Index
 )
 
+// channelNumber returns the Channel for the given channel number.  A
+// ParseError is returned if the number does not fit in a channel byte
+func channelNumber(number int) (Channel, error) {
+	if number < 0 || number > 255 {
+		return 0, newParseError(fmt.Sprintf("Channel number %d is out of range", number))
+	}
+	return Channel(number), nil
+}
+
+// ParseChannel returns the Channel for the given channel number or name. Names
+// are matched, without regard to case, against the string returned by
+// Channel.String().  A ParseError is returned for numbers outside the range
+// of a channel byte (0-255)
+func ParseChannel(name string) (Channel, error) {
+	if number, err := strconv.Atoi(name); err == nil {
+		return channelNumber(number)
+	}
+
+	for _, channel := range Channels() {
+		if strings.EqualFold(channel.String(), name) {
+			return channel, nil
+		}
+	}
+	return 0, newParseError(fmt.Sprintf("Unknown channel %q", name))
+}
+
+// UnmarshalJSON accepts either a channel number or a channel name, as
+// understood by ParseChannel
+func (channel *Channel) UnmarshalJSON(data []byte) (err error) {
+	var name string
+	if json.Unmarshal(data, &name) == nil {
+		*channel, err = ParseChannel(name)
+		return err
+	}
+
+	var number int
+	err = json.Unmarshal(data, &number)
+	if err == nil {
+		*channel, err = channelNumber(number)
+	}
+	return err
+}
+
+// channelLengths are the frame lengths of the data channels built in to
+// the data logger
 var channelLengths = map[Channel]int{
 	RunInformationChannel:             9,
 	RunStatusChannel:                  11,
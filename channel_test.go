@@ -0,0 +1,71 @@
+package dl
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseChannel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Channel
+		parseErr bool
+	}{
+		{"0", 0, false},
+		{"20", AnalogChannel1, false},
+		{"255", Channel(255), false},
+		{"Analog 1", AnalogChannel1, false},
+		{"speed data", SpeedDataChannel, false},
+		{"256", 0, true},
+		{"300", 0, true},
+		{"-1", 0, true},
+		{"Analog 99", 0, true},
+	}
+
+	for _, test := range tests {
+		channel, err := ParseChannel(test.input)
+		var parseErr *ParseError
+		if test.parseErr {
+			if !errors.As(err, &parseErr) {
+				t.Errorf("%q: expected a ParseError got %v", test.input, err)
+			}
+			continue
+		}
+
+		if err != nil || channel != test.expected {
+			t.Errorf("%q: expected %v got %v (%v)", test.input, test.expected, channel, err)
+		}
+	}
+}
+
+func TestChannelUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Channel
+		err      bool
+	}{
+		{`20`, AnalogChannel1, false},
+		{`"20"`, AnalogChannel1, false},
+		{`"Analog 1"`, AnalogChannel1, false},
+		{`300`, 0, true},
+		{`-1`, 0, true},
+		{`"300"`, 0, true},
+		{`true`, 0, true},
+	}
+
+	for _, test := range tests {
+		var channel Channel
+		err := json.Unmarshal([]byte(test.input), &channel)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error got %v", test.input, channel)
+			}
+			continue
+		}
+
+		if err != nil || channel != test.expected {
+			t.Errorf("%s: expected %v got %v (%v)", test.input, test.expected, channel, err)
+		}
+	}
+}
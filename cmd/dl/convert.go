@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/abates/dl"
+)
+
+var convertCommand = &command{
+	name:        "convert",
+	usage:       "[-format csv|json|gpx|kml] [-rate hz] [-calibration file] [-math file] [-o file] <run file>",
+	description: "Convert a run file to another format",
+	run:         convert,
+}
+
+// jsonAnalyzer writes each Epoch as a line of JSON
+type jsonAnalyzer struct {
+	encoder *json.Encoder
+}
+
+func (ja *jsonAnalyzer) Process(ctx context.Context, input <-chan dl.Sample, output chan<- dl.Sample) error {
+	for sample := range input {
+		if epoch, ok := sample.(*dl.Epoch); ok {
+			if err := ja.encoder.Encode(epoch); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func convert(ctx context.Context, flags *flag.FlagSet, args []string) (err error) {
+	format := flags.String("format", "csv", "output `format` (csv, json, gpx or kml)")
+	rate := flags.Float64("rate", 0, "resample the epochs to a fixed `rate` in hertz")
+	calibrationFile := flags.String("calibration", "", "convert the analog and frequency inputs using the sensor calibration in `file`")
+	mathFile := flags.String("math", "", "add the math channels defined in `file`")
+	outfile := flags.String("o", "", "write the output to `file` instead of stdout")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one run file")
+	}
+
+	var calibration *dl.Calibration
+	if *calibrationFile != "" {
+		if calibration, err = dl.LoadCalibration(*calibrationFile); err != nil {
+			return err
+		}
+	}
+
+	var mathChannels []*dl.MathChannel
+	if *mathFile != "" {
+		if mathChannels, err = dl.LoadMathChannels(*mathFile, calibration); err != nil {
+			return err
+		}
+	}
+
+	var output io.Writer = os.Stdout
+	if *outfile != "" {
+		var f *os.File
+		if f, err = os.Create(*outfile); err != nil {
+			return err
+		}
+
+		// a failure to flush the output must be reported
+		defer func() {
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		output = f
+	}
+
+	var analyzers []dl.Analyzer
+	switch *format {
+	case "csv":
+		analyzers = append(analyzers, dl.NewCSVAnalyzer(output))
+	case "json":
+		analyzers = append(analyzers, &jsonAnalyzer{encoder: json.NewEncoder(output)})
+	case "gpx":
+		analyzers = append(analyzers, dl.NewGPXAnalyzer(output))
+	case "kml":
+		analyzers = append(analyzers, dl.NewSectorAnalyzer(), dl.NewKMLAnalyzer(output))
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	file, _, chain, err := openRun(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	chain.Append(&dl.RunParser{}).Append(&dl.SampleDemuxer{})
+	if calibration != nil {
+		chain.Append(dl.NewCalibrationAnalyzer(calibration))
+	}
+
+	if len(mathChannels) > 0 {
+		chain.Append(dl.NewMathAnalyzer(mathChannels...))
+	}
+
+	if *rate > 0 {
+		chain.Append(dl.NewResampleAnalyzer(*rate))
+	}
+	for _, analyzer := range analyzers {
+		chain.Append(analyzer)
+	}
+	return chain.Wait()
+}
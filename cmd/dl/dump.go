@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/abates/dl"
+)
+
+// channelList is a flag value that accumulates channel names or numbers
+type channelList map[dl.Channel]bool
+
+func (cl channelList) String() string {
+	names := make([]string, 0, len(cl))
+	for channel := range cl {
+		names = append(names, channel.String())
+	}
+	return strings.Join(names, ",")
+}
+
+func (cl channelList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		channel, err := dl.ParseChannel(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		cl[channel] = true
+	}
+	return nil
+}
+
+// match indicates if the channel is in the list. An empty list
+// matches every channel
+func (cl channelList) match(channel dl.Channel) bool {
+	return len(cl) == 0 || cl[channel]
+}
+
+var dumpCommand = &command{
+	name:        "dump",
+	usage:       "[-channel name] <run file>",
+	description: "Print the raw messages in a run file",
+	run:         dump,
+}
+
+func dump(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	channels := make(channelList)
+	flags.Var(channels, "channel", "only print messages for the given channel `name` or number (may be repeated or comma separated)")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one run file")
+	}
+
+	file, _, chain, err := openRun(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for sample := range chain.Output() {
+		if message, ok := sample.(*dl.Message); ok && channels.match(message.Channel) {
+			fmt.Printf("%08x %v\n", message.Offset, message)
+		}
+	}
+	return chain.Wait()
+}
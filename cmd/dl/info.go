@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/abates/dl"
+)
+
+var infoCommand = &command{
+	name:        "info",
+	usage:       "[-json] [-track file] <run file>",
+	description: "Print a summary of a run file",
+	run:         info,
+}
+
+func info(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	jsonOutput := flags.Bool("json", false, "print the session summary as JSON")
+	trackFile := flags.String("track", "", "detect laps using the start/finish and sector lines of the track definition in `file`")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one run file")
+	}
+
+	sectorAnalyzer := dl.NewSectorAnalyzer()
+	bestLapAnalyzer := dl.NewBestLapAnalyzer()
+	if *trackFile != "" {
+		track, err := dl.LoadTrack(*trackFile)
+		if err != nil {
+			return err
+		}
+
+		if sectorInfo := track.SectorInfo(); sectorInfo != nil {
+			sectorAnalyzer.SectorInfo(sectorInfo)
+			bestLapAnalyzer.SectorInfo(sectorInfo)
+		}
+	}
+
+	file, reader, chain, err := openRun(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	counts := make(dl.ChannelCounter)
+	chain.Append(counts).Append(&dl.RunParser{}).Append(&dl.SampleDemuxer{})
+	chain.Append(sectorAnalyzer).Append(bestLapAnalyzer).Append(dl.NewSessionSummaryAnalyzer())
+
+	var summary *dl.SessionSummary
+	var analysis *dl.LapAnalysis
+	var corrupted []*dl.Corruption
+	decodeErrors := make(map[dl.Channel]int)
+
+	for sample := range chain.Output() {
+		switch v := sample.(type) {
+		case *dl.SessionSummary:
+			summary = v
+		case *dl.LapAnalysis:
+			analysis = v
+		case *dl.DecodeError:
+			decodeErrors[v.Message.Channel]++
+		case *dl.Corruption:
+			corrupted = append(corrupted, v)
+		}
+	}
+
+	if err := chain.Wait(); err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		buf, err := json.MarshalIndent(summary, "", "  ")
+		if err == nil {
+			_, err = fmt.Printf("%s\n", buf)
+		}
+		return err
+	}
+
+	fmt.Printf("File:            %s\n", flags.Arg(0))
+	if runInfo := summary.RunInformation; runInfo != nil {
+		fmt.Printf("Run header:      %v\n", runInfo)
+	}
+
+	if logger := summary.Logger; logger != nil {
+		fmt.Printf("Logger serial:   %d (software %d, bootloader %d)\n", logger.SerialNumber, logger.SoftwareVersion, logger.BootloadVersion)
+	}
+
+	if startStop := summary.StartStopInfo; startStop.StartMethod != 0 {
+		fmt.Printf("Start method:    %v\n", startStop.StartMethod)
+		fmt.Printf("Stop method:     %v\n", startStop.StopMethod)
+	}
+
+	if !summary.Start.IsZero() {
+		fmt.Printf("Started:         %v\n", summary.Start)
+	}
+
+	fmt.Printf("Duration:        %v\n", time.Duration(summary.Duration)*time.Millisecond)
+	if summary.Laps > 0 {
+		fmt.Printf("Laps:            %d\n", summary.Laps)
+		fmt.Printf("Best lap:        %v (lap %d)\n", time.Duration(summary.BestLapTime)*time.Millisecond, summary.BestLap)
+		fmt.Printf("Average lap:     %v (deviation %v)\n", time.Duration(summary.AverageLapTime)*time.Millisecond, time.Duration(summary.LapTimeDeviation)*time.Millisecond)
+	}
+	fmt.Printf("Top speed:       %.2f\n", summary.TopSpeed)
+	fmt.Printf("Max G:           %.2f lateral, %.2f acceleration, %.2f braking, %.2f vector\n", summary.MaxLateralAcceleration, summary.MaxAcceleration, summary.MaxBraking, summary.MaxVectorAcceleration)
+	printLaps(analysis)
+	fmt.Printf("Checksum errors: %d\n", reader.ChecksumErrors())
+	fmt.Printf("Corrupt regions: %d\n", len(corrupted))
+	for _, corruption := range corrupted {
+		fmt.Printf("  %v\n", corruption)
+	}
+	fmt.Printf("Channels:%37s  %13s\n", "messages", "decode errors")
+
+	channels := make([]dl.Channel, 0, len(counts))
+	for channel := range counts {
+		channels = append(channels, channel)
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i] < channels[j] })
+
+	for _, channel := range channels {
+		fmt.Printf("  %3d %-30v %9d  %13d\n", int(channel), channel, counts[channel], decodeErrors[channel])
+	}
+	return nil
+}
+
+// seconds formats a time offset as seconds
+func seconds(offset dl.TimeOffset) float64 { return float64(offset) / 1000 }
+
+// printLaps prints the lap time and sector times of every lap, with the
+// difference from the best lap and best sectors
+func printLaps(analysis *dl.LapAnalysis) {
+	if analysis == nil || len(analysis.Laps) == 0 {
+		return
+	}
+
+	fmt.Printf("Theoretical:     %.3fs\n", seconds(analysis.TheoreticalBest))
+	fmt.Printf("Rolling best:    %.3fs (from lap %d sector %d)\n", seconds(analysis.RollingBest), analysis.RollingBestLap, analysis.RollingBestSector)
+	fmt.Printf("  Lap       Time     Delta  Sectors (delta)\n")
+	for _, lap := range analysis.Laps {
+		fmt.Printf("  %3d %9.3fs %+8.3fs ", lap.Lap, seconds(lap.Time), seconds(lap.Delta))
+		for _, sector := range lap.Sectors {
+			fmt.Printf(" %d: %.3fs (%+.3f)", sector.Sector, seconds(sector.Time), seconds(sector.Delta))
+		}
+		fmt.Printf("\n")
+	}
+}
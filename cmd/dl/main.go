@@ -0,0 +1,109 @@
+// dl is a command line tool for inspecting and converting data logger
+// run files
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime/pprof"
+
+	"github.com/abates/dl"
+)
+
+// command is a single dl sub-command
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(ctx context.Context, flags *flag.FlagSet, args []string) error
+}
+
+var commands = []*command{dumpCommand, infoCommand, convertCommand, trackCommand}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: dl [flags] <command> [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// newFlagSet returns a flag set for the command that prints the command's
+// usage on error
+func (cmd *command) newFlagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dl %s %s\n\n%s\n", cmd.name, cmd.usage, cmd.description)
+		if hasFlags(flags) {
+			fmt.Fprintf(os.Stderr, "\nFlags:\n")
+			flags.PrintDefaults()
+		}
+	}
+	return flags
+}
+
+func hasFlags(flags *flag.FlagSet) (found bool) {
+	flags.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// openRun opens a run file and starts a processing chain reading from it
+func openRun(ctx context.Context, filename string) (*os.File, *dl.RunReader, *dl.ProcessingChain, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	reader := dl.NewRunReader(file)
+	return file, reader, dl.NewProcessingChain(ctx, reader), nil
+}
+
+func main() {
+	cpuprofile := flag.String("cpuprofile", "", "write a CPU profile to `file`")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == flag.Arg(0) {
+			cmd = c
+			break
+		}
+	}
+
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "dl: unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	if *cpuprofile != "" {
+		f, err := os.Create(*cpuprofile)
+		if err == nil {
+			err = pprof.StartCPUProfile(f)
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "dl: could not start CPU profile: %v\n", err)
+			os.Exit(1)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.run(ctx, cmd.newFlagSet(), flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "dl %s: %v\n", cmd.name, err)
+		pprof.StopCPUProfile()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/abates/dl"
+)
+
+var trackCommand = &command{
+	name:        "track",
+	usage:       "-name name [-o file] <run file>",
+	description: "Create a track definition from the fastest lap and lap markers of a run file",
+	run:         track,
+}
+
+func track(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	name := flags.String("name", "", "the `name` of the track")
+	direction := flags.String("direction", "", "the `direction` of the track (clockwise or counterclockwise)")
+	outfile := flags.String("o", "", "write the track to `file` instead of stdout")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || *name == "" {
+		flags.Usage()
+		return fmt.Errorf("expected a track name and one run file")
+	}
+
+	if *direction != "" && *direction != dl.Clockwise && *direction != dl.CounterClockwise {
+		return fmt.Errorf("unknown direction %q", *direction)
+	}
+
+	file, _, chain, err := openRun(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	chain.Append(&dl.RunParser{}).Append(&dl.SampleDemuxer{}).Append(dl.NewSectorAnalyzer())
+
+	sectorInfo := &dl.SectorInfo{}
+	var best *dl.Lap
+	for sample := range chain.Output() {
+		switch v := sample.(type) {
+		case *dl.LapMarker:
+			sectorInfo.AddMarker(v)
+		case *dl.Lap:
+			if best == nil || v.Elapsed() < best.Elapsed() {
+				best = v
+			}
+		}
+	}
+
+	if err := chain.Wait(); err != nil {
+		return err
+	}
+
+	if best == nil {
+		return fmt.Errorf("%s: no complete laps", flags.Arg(0))
+	}
+
+	trackMap := dl.NewLapTrackMap(best)
+	trackMap.Name = *name
+	trackMap.Direction = *direction
+	for i, marker := range sectorInfo.Markers() {
+		line := dl.TrackLine{Latitude: marker.Latitude, Longitude: marker.Longitude, Heading: marker.Heading}
+		if i == 0 {
+			trackMap.StartFinish = &line
+		} else {
+			trackMap.Sectors = append(trackMap.Sectors, line)
+		}
+	}
+
+	if *outfile != "" {
+		return dl.SaveTrack(*outfile, trackMap)
+	}
+	return dl.WriteTrack(os.Stdout, trackMap)
+}
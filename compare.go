@@ -0,0 +1,242 @@
+package dl
+
+import (
+	"context"
+	"math"
+	"os"
+)
+
+// LoadLaps reads a run file and returns the complete laps that it contains.
+// Laps are detected using the given sector information or, if sectorInfo
+// is nil, the lap markers recorded in the file.  The analyzers, such as a
+// CalibrationAnalyzer, are run on the Epochs after the laps have been
+// detected
+func LoadLaps(ctx context.Context, filename string, sectorInfo *SectorInfo, analyzers ...Analyzer) ([]*Lap, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sectorAnalyzer := NewSectorAnalyzer()
+	if sectorInfo != nil {
+		sectorAnalyzer.SectorInfo(sectorInfo)
+	}
+
+	chain := NewProcessingChain(ctx, NewRunReader(file))
+	chain.Append(&RunParser{}).Append(&SampleDemuxer{}).Append(sectorAnalyzer)
+	for _, analyzer := range analyzers {
+		chain.Append(analyzer)
+	}
+
+	var laps []*Lap
+	for sample := range chain.Output() {
+		if lap, ok := sample.(*Lap); ok {
+			laps = append(laps, lap)
+		}
+	}
+	return laps, chain.Wait()
+}
+
+// LapSeries are the values of one lap sampled at each distance of a
+// Comparison.  Values that were not recorded are NaN
+type LapSeries struct {
+	// Lap is the lap the values were taken from
+	Lap *Lap
+
+	// Time is the elapsed lap time
+	Time []TimeOffset
+
+	// Delta is the difference between Time and the elapsed time of the
+	// reference lap.  Positive values are behind the reference lap
+	Delta []TimeOffset
+
+	// Speed is the vehicle speed
+	Speed []Speed
+
+	// LateralAcceleration is the lateral acceleration
+	LateralAcceleration []Acceleration
+
+	// LongitudinalAcceleration is the longitudinal acceleration
+	LongitudinalAcceleration []Acceleration
+
+	// Channels are the analog inputs (in millivolts) and measurements,
+	// keyed by channel or measurement name
+	Channels map[string][]float64
+}
+
+// Comparison contains two or more laps aligned by distance around the
+// track, so that the values of each lap can be overlaid
+type Comparison struct {
+	// Distance is the distance along the track of each sample
+	Distance []Distance
+
+	// Laps are the values of each lap at each distance.  The first lap
+	// is the reference lap for the Delta time
+	Laps []*LapSeries
+}
+
+// maxProjectionJump is the furthest (in meters) that a position projected
+// on to a track map may be from the distance expected from the previous
+// Epoch.  Projections further away, such as where the track passes close
+// to itself or during a GPS glitch, are rejected
+const maxProjectionJump = 25.0
+
+// lapAlignment maps the Epochs of a lap to the distance along a track
+type lapAlignment struct {
+	distances []float64
+	epochs    []*Epoch
+	next      int
+}
+
+// alignLap computes the distance along the track of each Epoch in the lap.
+// Without a track map the lap distance is scaled so that the lap has the
+// given length.  With a track map the positions are projected onto the map,
+// unless the projection is more than maxProjectionJump from the previous
+// distance plus the scaled distance travelled since, in which case that
+// expected distance is used instead.  A map without a start/finish line is
+// measured from its first way point, so the distances are then offset to
+// start from the first position of the lap
+func alignLap(lap *Lap, trackMap *TrackMap, length Distance) *lapAlignment {
+	alignment := &lapAlignment{distances: make([]float64, len(lap.Epochs)), epochs: lap.Epochs}
+	lapLength := lap.Epochs[len(lap.Epochs)-1].LapDistance
+
+	seeded := trackMap == nil || trackMap.StartFinish != nil
+	origin, prev, prevLapDistance := 0.0, 0.0, Distance(0)
+	for i, epoch := range lap.Epochs {
+		travelled := 0.0
+		if lapLength > 0 {
+			travelled = float64((epoch.LapDistance - prevLapDistance) / lapLength * length)
+		}
+		distance := prev + travelled
+
+		if trackMap != nil && hasPosition(epoch) {
+			expected := distance
+			projected := float64(trackMap.Project(epoch.Latitude, epoch.Longitude))
+			if !seeded {
+				origin, seeded = projected-expected, true
+			}
+			projected -= origin
+
+			// positions near the Start/Finish line can be projected on to either
+			// end of the map, so use the one closest to the expected distance
+			for _, candidate := range []float64{projected - float64(length), projected + float64(length)} {
+				if math.Abs(candidate-expected) < math.Abs(projected-expected) {
+					projected = candidate
+				}
+			}
+
+			if math.Abs(projected-expected) <= maxProjectionJump {
+				distance = projected
+			}
+		}
+
+		alignment.distances[i] = distance
+		prev, prevLapDistance = distance, epoch.LapDistance
+	}
+	return alignment
+}
+
+// at returns an Epoch interpolated to the given distance.  Distances must be
+// requested in increasing order
+func (la *lapAlignment) at(distance float64) *Epoch {
+	for la.next < len(la.distances) && la.distances[la.next] < distance {
+		la.next++
+	}
+
+	i := la.next
+	if i == 0 {
+		return la.epochs[0]
+	} else if i == len(la.distances) {
+		return la.epochs[len(la.epochs)-1]
+	}
+
+	d0, d1 := la.distances[i-1], la.distances[i]
+	return interpolateEpoch(la.epochs[i-1], la.epochs[i], (distance-d0)/(d1-d0))
+}
+
+// CompareLaps aligns the laps by distance and samples each of them at
+// every step (in meters) along the track.  If a track map is given each
+// position is projected onto the map, so that laps driven on different
+// lines, or from different run files, line up.  Without a track map the
+// laps are aligned by the proportion of the lap distance covered.  The
+// comparison ends at the shortest of the aligned laps
+func CompareLaps(trackMap *TrackMap, step Distance, laps ...*Lap) (*Comparison, error) {
+	if step <= 0 {
+		return nil, ErrInvalidStep
+	}
+
+	if len(laps) == 0 {
+		return nil, ErrNoLaps
+	}
+
+	for _, lap := range laps {
+		if len(lap.Epochs) == 0 {
+			return nil, ErrNoLaps
+		}
+	}
+
+	length := laps[0].Epochs[len(laps[0].Epochs)-1].LapDistance
+	if trackMap != nil {
+		length = trackMap.Length()
+	}
+
+	alignments := make([]*lapAlignment, len(laps))
+	end := math.Inf(1)
+	channels := make(map[string]bool)
+	for i, lap := range laps {
+		alignments[i] = alignLap(lap, trackMap, length)
+		end = math.Min(end, alignments[i].distances[len(alignments[i].distances)-1])
+		for _, epoch := range lap.Epochs {
+			for channel := range epoch.AnalogInputs {
+				channels[channel.String()] = true
+			}
+
+			for name := range epoch.Measurements {
+				channels[name] = true
+			}
+		}
+	}
+
+	comparison := &Comparison{}
+	for distance := 0.0; distance <= end; distance += float64(step) {
+		comparison.Distance = append(comparison.Distance, Distance(distance))
+	}
+
+	for i, lap := range laps {
+		series := &LapSeries{Lap: lap, Channels: make(map[string][]float64)}
+		for _, distance := range comparison.Distance {
+			epoch := alignments[i].at(float64(distance))
+			series.Time = append(series.Time, epoch.LapTime)
+			series.Speed = append(series.Speed, epoch.Speed)
+			series.LateralAcceleration = append(series.LateralAcceleration, epoch.LateralAcceleration)
+			series.LongitudinalAcceleration = append(series.LongitudinalAcceleration, epoch.LongitudinalAcceleration)
+
+			values := make(map[string]float64)
+			for channel, voltage := range epoch.AnalogInputs {
+				values[channel.String()] = float64(voltage)
+			}
+
+			for name, measurement := range epoch.Measurements {
+				values[name] = measurement.Value
+			}
+
+			for name := range channels {
+				value, found := values[name]
+				if !found {
+					value = math.NaN()
+				}
+				series.Channels[name] = append(series.Channels[name], value)
+			}
+		}
+		comparison.Laps = append(comparison.Laps, series)
+	}
+
+	for _, series := range comparison.Laps {
+		series.Delta = make([]TimeOffset, len(series.Time))
+		for i, time := range series.Time {
+			series.Delta[i] = time - comparison.Laps[0].Time[i]
+		}
+	}
+	return comparison, nil
+}
@@ -0,0 +1,136 @@
+package dl
+
+import (
+	"math"
+	"testing"
+)
+
+// circleLap drives one clockwise lap of the circular test track, starting
+// at the northern most point, in the given lap time.  The position of the
+// Epoch at glitch, if it is positive, is moved to the far side of the track
+func circleLap(number int, lapTime TimeOffset, glitch int) *Lap {
+	lap := &Lap{Number: number, Stop: lapTime}
+	steps := 100
+	for i := 0; i <= steps; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(steps)
+		if glitch > 0 && i == glitch {
+			angle += math.Pi
+		}
+
+		epoch := positionEpoch(TimeOffset(i)*lapTime/TimeOffset(steps), circleRadius*math.Sin(angle), circleRadius*math.Cos(angle), 0)
+		epoch.Lap = number
+		epoch.LapTime = epoch.Stop
+		epoch.LapDistance = Distance(2 * math.Pi * circleRadius * float64(i) / float64(steps))
+		lap.Epochs = append(lap.Epochs, epoch)
+	}
+	return lap
+}
+
+// circleTrackMap returns a map of the circular test track with the way
+// points starting at the given angle and the start/finish line at the
+// northern most point
+func circleTrackMap(start float64) *TrackMap {
+	lat, lon := position(0, circleRadius)
+	tm := &TrackMap{Name: "circle", StartFinish: &TrackLine{Latitude: lat, Longitude: lon, Heading: 90}}
+	for i := 0; i <= 360; i++ {
+		angle := start + 2*math.Pi*float64(i)/360
+		lat, lon := position(circleRadius*math.Sin(angle), circleRadius*math.Cos(angle))
+		tm.WayPoints = append(tm.WayPoints, WayPoint{Latitude: lat, Longitude: lon})
+	}
+	return tm
+}
+
+func TestCompareLaps(t *testing.T) {
+	circumference := 2 * math.Pi * circleRadius
+	tests := []struct {
+		name     string
+		trackMap *TrackMap
+		glitch   int
+	}{
+		{"lap distance", nil, 0},
+		{"track map", circleTrackMap(0), 0},
+		{"track map starting after start/finish", circleTrackMap(math.Pi / 2), 0},
+		{"glitch", circleTrackMap(math.Pi / 2), 30},
+	}
+
+	for _, test := range tests {
+		comparison, err := CompareLaps(test.trackMap, 10, circleLap(1, 10000, 0), circleLap(2, 11000, test.glitch))
+		if err != nil {
+			t.Errorf("%s: CompareLaps() returned %v", test.name, err)
+			continue
+		}
+
+		if len(comparison.Distance) < 60 || len(comparison.Laps) != 2 {
+			t.Errorf("%s: expected at least 60 distances for 2 laps got %d for %d", test.name, len(comparison.Distance), len(comparison.Laps))
+			continue
+		}
+
+		for i, distance := range comparison.Distance {
+			fraction := float64(distance) / circumference
+			reference, delta := comparison.Laps[0].Time[i], comparison.Laps[1].Delta[i]
+			if expected := 10000 * fraction; math.Abs(float64(reference)-expected) > 10 {
+				t.Errorf("%s: at %v expected reference time %v got %v", test.name, distance, expected, reference)
+			}
+
+			if expected := 1000 * fraction; math.Abs(float64(delta)-expected) > 10 {
+				t.Errorf("%s: at %v expected delta %v got %v", test.name, distance, expected, delta)
+			}
+		}
+	}
+}
+
+func TestAlignLapWithoutStartFinish(t *testing.T) {
+	// the way points start a quarter of a lap after the start of the lap
+	trackMap := &TrackMap{WayPoints: circleTrackMap(math.Pi / 2).WayPoints}
+	lap := circleLap(1, 10000, 0)
+
+	// without a lap distance only the projections on to the map can place
+	// the Epochs along the track
+	for _, epoch := range lap.Epochs {
+		epoch.LapDistance = 0
+	}
+
+	alignment := alignLap(lap, trackMap, trackMap.Length())
+	for i, distance := range alignment.distances {
+		if expected := 2 * math.Pi * circleRadius * float64(i) / 100; math.Abs(distance-expected) > 0.5 {
+			t.Errorf("epoch %d: expected distance %v got %v", i, expected, distance)
+		}
+	}
+}
+
+func TestCompareLapsErrors(t *testing.T) {
+	if _, err := CompareLaps(nil, 0, circleLap(1, 10000, 0)); err != ErrInvalidStep {
+		t.Errorf("expected %v got %v", ErrInvalidStep, err)
+	}
+
+	if _, err := CompareLaps(nil, 10); err != ErrNoLaps {
+		t.Errorf("expected %v got %v", ErrNoLaps, err)
+	}
+
+	if _, err := CompareLaps(nil, 10, &Lap{}); err != ErrNoLaps {
+		t.Errorf("expected %v got %v", ErrNoLaps, err)
+	}
+}
+
+func TestTrackMapProject(t *testing.T) {
+	circumference := 2 * math.Pi * circleRadius
+	tests := []struct {
+		name     string
+		trackMap *TrackMap
+		angle    float64
+		expected float64
+	}{
+		{"start/finish", circleTrackMap(math.Pi / 2), 0, 0},
+		{"after start/finish", circleTrackMap(math.Pi / 2), math.Pi / 4, circumference / 8},
+		{"far side", circleTrackMap(math.Pi / 2), 3 * math.Pi / 2, circumference * 3 / 4},
+		{"before start/finish", circleTrackMap(math.Pi / 2), -math.Pi / 4, circumference * 7 / 8},
+		{"first way point", &TrackMap{WayPoints: circleTrackMap(math.Pi / 2).WayPoints}, math.Pi, circumference / 4},
+	}
+
+	for _, test := range tests {
+		lat, lon := position(circleRadius*math.Sin(test.angle), circleRadius*math.Cos(test.angle))
+		if distance := test.trackMap.Project(lat, lon); math.Abs(float64(distance)-test.expected) > 0.5 {
+			t.Errorf("%s: expected %v got %v", test.name, test.expected, distance)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package dl
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// csvColumn is a single fixed column of the CSV output
+type csvColumn struct {
+	name  string
+	value func(epoch *Epoch) string
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+var csvColumns = []csvColumn{
+	{"Time (ms)", func(epoch *Epoch) string { return strconv.FormatInt(int64(epoch.Stop), 10) }},
+	{"GPS Time (ms)", func(epoch *Epoch) string { return strconv.FormatUint(uint64(epoch.GPSTime), 10) }},
+	{"Latitude (°)", func(epoch *Epoch) string { return formatFloat(float64(epoch.Latitude)) }},
+	{"Longitude (°)", func(epoch *Epoch) string { return formatFloat(float64(epoch.Longitude)) }},
+	{"Distance (m)", func(epoch *Epoch) string { return formatFloat(float64(epoch.Distance)) }},
+	{"Lap Distance (m)", func(epoch *Epoch) string { return formatFloat(float64(epoch.LapDistance)) }},
+	{"Speed (m/s)", func(epoch *Epoch) string { return formatFloat(float64(epoch.Speed)) }},
+	{"Engine Speed (rpm)", func(epoch *Epoch) string { return formatFloat(float64(epoch.EngineSpeed)) }},
+	{"Lateral Acceleration (G)", func(epoch *Epoch) string { return formatFloat(float64(epoch.LateralAcceleration)) }},
+	{"Longitudinal Acceleration (G)", func(epoch *Epoch) string { return formatFloat(float64(epoch.LongitudinalAcceleration)) }},
+	{"Vector Acceleration (G)", func(epoch *Epoch) string { return formatFloat(float64(epoch.VectorAcceleration)) }},
+	{"Vertical Acceleration (G)", func(epoch *Epoch) string { return formatFloat(float64(epoch.VerticalAcceleration)) }},
+	{"Yaw Rate (°/s)", func(epoch *Epoch) string { return formatFloat(float64(epoch.YawRate)) }},
+	{"Yaw (°)", func(epoch *Epoch) string { return formatFloat(float64(epoch.Yaw)) }},
+	{"Pitch Rate (°/s)", func(epoch *Epoch) string { return formatFloat(float64(epoch.PitchRate)) }},
+	{"Pitch (°)", func(epoch *Epoch) string { return formatFloat(float64(epoch.Pitch)) }},
+	{"Roll Rate (°/s)", func(epoch *Epoch) string { return formatFloat(float64(epoch.RollRate)) }},
+	{"Roll (°)", func(epoch *Epoch) string { return formatFloat(float64(epoch.Roll)) }},
+	{"Gradient (°)", func(epoch *Epoch) string { return formatFloat(float64(epoch.Gradient)) }},
+	{"Heading (°)", func(epoch *Epoch) string { return formatFloat(float64(epoch.Heading)) }},
+	{"Altitude (mm)", func(epoch *Epoch) string { return strconv.Itoa(int(epoch.Altitude)) }},
+	{"GPS Accuracy (mm)", func(epoch *Epoch) string { return strconv.Itoa(int(epoch.GPSAccuracy)) }},
+	{"Heading Accuracy (°)", func(epoch *Epoch) string { return formatFloat(float64(epoch.HeadingAccuracy)) }},
+	{"Altitude Accuracy (mm)", func(epoch *Epoch) string { return strconv.Itoa(int(epoch.AltitudeAccuracy)) }},
+}
+
+// CSVAnalyzer writes every Epoch as a row of comma separated values.  In
+// addition to the fixed columns, one column is written for each analog
+// and frequency input, and each measurement, reported anywhere in the
+// run.  Since the set of columns isn't known until the end of the run, the
+// rows are written once the input is closed.  All input samples are passed
+// to the output
+type CSVAnalyzer struct {
+	writer io.Writer
+}
+
+// NewCSVAnalyzer returns a CSVAnalyzer that writes to the given writer
+func NewCSVAnalyzer(writer io.Writer) *CSVAnalyzer {
+	return &CSVAnalyzer{writer: writer}
+}
+
+// Process will start the CSV loop.  This should usually be run in
+// a go routine
+func (ca *CSVAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	var epochs []*Epoch
+	analogChannels := make(map[Channel]bool)
+	frequencyChannels := make(map[Channel]bool)
+	measurementUnits := make(map[string]string)
+
+	for sample := range input {
+		if epoch, ok := sample.(*Epoch); ok {
+			epochs = append(epochs, epoch)
+			for channel := range epoch.AnalogInputs {
+				analogChannels[channel] = true
+			}
+
+			for channel := range epoch.FrequencyInputs {
+				frequencyChannels[channel] = true
+			}
+
+			for name, measurement := range epoch.Measurements {
+				measurementUnits[name] = measurement.Unit
+			}
+		}
+
+		if err := send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+
+	analog := sortedChannels(analogChannels)
+	frequency := sortedChannels(frequencyChannels)
+	measurements := make([]string, 0, len(measurementUnits))
+	for name := range measurementUnits {
+		measurements = append(measurements, name)
+	}
+	sort.Strings(measurements)
+
+	header := make([]string, 0, len(csvColumns)+len(analog)+len(frequency)+len(measurements))
+	for _, column := range csvColumns {
+		header = append(header, column.name)
+	}
+
+	for _, channel := range analog {
+		header = append(header, fmt.Sprintf("%v (mV)", channel))
+	}
+
+	for _, channel := range frequency {
+		header = append(header, fmt.Sprintf("%v (hz)", channel))
+	}
+
+	for _, name := range measurements {
+		if unit := measurementUnits[name]; unit != "" {
+			name = fmt.Sprintf("%s (%s)", name, unit)
+		}
+		header = append(header, name)
+	}
+
+	writer := csv.NewWriter(ca.writer)
+	writer.Write(header)
+	row := make([]string, len(header))
+	for _, epoch := range epochs {
+		row = row[:0]
+		for _, column := range csvColumns {
+			row = append(row, column.value(epoch))
+		}
+
+		for _, channel := range analog {
+			value := ""
+			if voltage, found := epoch.AnalogInputs[channel]; found {
+				value = strconv.Itoa(int(voltage))
+			}
+			row = append(row, value)
+		}
+
+		for _, channel := range frequency {
+			value := ""
+			if freq, found := epoch.FrequencyInputs[channel]; found {
+				value = formatFloat(float64(freq))
+			}
+			row = append(row, value)
+		}
+
+		for _, name := range measurements {
+			value := ""
+			if measurement, found := epoch.Measurements[name]; found {
+				value = formatFloat(measurement.Value)
+			}
+			row = append(row, value)
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// sortedChannels returns the channels in the set ordered by channel number
+func sortedChannels(set map[Channel]bool) []Channel {
+	channels := make([]Channel, 0, len(set))
+	for channel := range set {
+		channels = append(channels, channel)
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i] < channels[j] })
+	return channels
+}
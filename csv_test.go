@@ -0,0 +1,95 @@
+package dl
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestCSVAnalyzer(t *testing.T) {
+	samples := []Sample{
+		&Epoch{
+			Stop:         100,
+			Latitude:     40.5,
+			Longitude:    -75.25,
+			Speed:        12.5,
+			EngineSpeed:  4500,
+			Yaw:          90,
+			Roll:         -1.5,
+			AnalogInputs: map[Channel]Voltage{AnalogChannel2: 1200},
+		},
+		&Epoch{
+			Stop:                 200,
+			Speed:                13,
+			VerticalAcceleration: 0.25,
+			Gradient:             2,
+			FrequencyInputs:      map[Channel]Frequency{FrequencyChannel1: 60},
+			Measurements:         map[string]Measurement{"Oil": {Value: 95.5, Unit: "°C"}, "Ratio": {Value: 2}},
+		},
+		&Lap{Number: 1},
+	}
+
+	buf := &bytes.Buffer{}
+	output := runAnalyzers(t, samples, NewCSVAnalyzer(buf))
+	if len(output) != len(samples) {
+		t.Errorf("expected %d samples to be passed through got %d", len(samples), len(output))
+	}
+
+	records, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() returned %v", err)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("expected a header and 2 rows got %d records", len(records))
+	}
+
+	expected := []string{
+		"Time (ms)", "GPS Time (ms)", "Latitude (°)", "Longitude (°)", "Distance (m)", "Lap Distance (m)",
+		"Speed (m/s)", "Engine Speed (rpm)", "Lateral Acceleration (G)", "Longitudinal Acceleration (G)",
+		"Vector Acceleration (G)", "Vertical Acceleration (G)", "Yaw Rate (°/s)", "Yaw (°)", "Pitch Rate (°/s)",
+		"Pitch (°)", "Roll Rate (°/s)", "Roll (°)", "Gradient (°)", "Heading (°)", "Altitude (mm)",
+		"GPS Accuracy (mm)", "Heading Accuracy (°)", "Altitude Accuracy (mm)",
+		"Analog 2 (mV)", "Frequency 1 (hz)", "Oil (°C)", "Ratio",
+	}
+
+	if !reflect.DeepEqual(records[0], expected) {
+		t.Fatalf("expected header %q got %q", expected, records[0])
+	}
+
+	column := make(map[string]int)
+	for i, name := range records[0] {
+		column[name] = i
+	}
+
+	tests := []struct {
+		row      int
+		column   string
+		expected string
+	}{
+		{1, "Time (ms)", "100"},
+		{1, "Latitude (°)", "40.5"},
+		{1, "Longitude (°)", "-75.25"},
+		{1, "Speed (m/s)", "12.5"},
+		{1, "Engine Speed (rpm)", "4500"},
+		{1, "Yaw (°)", "90"},
+		{1, "Roll (°)", "-1.5"},
+		{1, "Analog 2 (mV)", "1200"},
+		{1, "Frequency 1 (hz)", ""},
+		{1, "Oil (°C)", ""},
+		{2, "Time (ms)", "200"},
+		{2, "Vertical Acceleration (G)", "0.25"},
+		{2, "Gradient (°)", "2"},
+		{2, "Analog 2 (mV)", ""},
+		{2, "Frequency 1 (hz)", "60"},
+		{2, "Oil (°C)", "95.5"},
+		{2, "Ratio", "2"},
+	}
+
+	for _, test := range tests {
+		if value := records[test.row][column[test.column]]; value != test.expected {
+			t.Errorf("row %d %s: expected %q got %q", test.row, test.column, test.expected, value)
+		}
+	}
+}
@@ -1,8 +1,35 @@
 package dl
 
+import (
+	"context"
+)
+
 // SampleDemuxer will read a parse stream and demultiplex the samples into
-// time slices. These timeslices will be represented as Epochs
+// time slices. These timeslices will be represented as Epochs.  The
+// cumulative distance of each Epoch is calculated from the change in GPS
+// position, unless the data logger provides pre-calculated distance data.
+// StartStopInfo samples are also passed to the output, since the data
+// logger may report how the session stopped after the final Timestamp
 type SampleDemuxer struct {
+	// IntegrateSpeed calculates the distance by integrating the speed
+	// over time instead of from the change in GPS position
+	IntegrateSpeed bool
+}
+
+// distance returns the distance travelled between two epochs
+func (dm *SampleDemuxer) distance(prev, cur *Epoch) Distance {
+	if prev == nil {
+		return 0
+	}
+
+	if dm.IntegrateSpeed {
+		return Distance(float64(prev.Speed+cur.Speed) / 2 * float64(cur.Stop-prev.Stop) / 1000)
+	}
+
+	if hasPosition(prev) && hasPosition(cur) {
+		return Distance(greatCircle(prev.Latitude, prev.Longitude, cur.Latitude, cur.Longitude))
+	}
+	return 0
 }
 
 func copyEpoch(input *Epoch) *Epoch {
@@ -18,16 +45,25 @@ func copyEpoch(input *Epoch) *Epoch {
 		output.FrequencyInputs[k] = v
 	}
 
+	output.Measurements = make(map[string]Measurement)
+	for k, v := range input.Measurements {
+		output.Measurements[k] = v
+	}
+
 	return output
 }
 
-// Demux will start the demux loop.  This should usually be run in
+// Process will start the demux loop.  This should usually be run in
 // a go routine
-func (dm *SampleDemuxer) Process(input <-chan Sample, output chan<- Sample) {
+func (dm *SampleDemuxer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) (err error) {
 	epoch := &Epoch{
 		AnalogInputs:    make(map[Channel]Voltage),
 		FrequencyInputs: make(map[Channel]Frequency),
+		Measurements:    make(map[string]Measurement),
 	}
+
+	var prev *Epoch
+	loggerDistance := false
 	for sample := range input {
 		switch v := sample.(type) {
 		case *GPSTimeStorage:
@@ -38,7 +74,11 @@ func (dm *SampleDemuxer) Process(input <-chan Sample, output chan<- Sample) {
 			epoch.VectorAcceleration = v.Vector()
 		case *Timestamp:
 			epoch.Stop = v.Timestamp
-			output <- copyEpoch(epoch)
+			if !loggerDistance {
+				epoch.Distance += dm.distance(prev, epoch)
+			}
+			prev = copyEpoch(epoch)
+			err = send(ctx, output, prev)
 			epoch.Start = v.Timestamp
 		case *GPSPosition:
 			epoch.Latitude = v.Latitude
@@ -48,6 +88,10 @@ func (dm *SampleDemuxer) Process(input <-chan Sample, output chan<- Sample) {
 			epoch.Speed = v.Speed
 		case *FrequencyInput:
 			epoch.FrequencyInputs[v.Channel] = v.Frequency
+		case *ExtendedFrequencyInput:
+			epoch.FrequencyInputs[v.Channel] = v.Frequency
+		case *RPM:
+			epoch.EngineSpeed = v.Speed
 		case *AnalogInput:
 			epoch.AnalogInputs[v.Channel] = v.Voltage
 		case *DateStorage:
@@ -55,14 +99,39 @@ func (dm *SampleDemuxer) Process(input <-chan Sample, output chan<- Sample) {
 		case *CourseData:
 			epoch.Heading = v.Heading
 			epoch.HeadingAccuracy = v.Accuracy
+		case *PreCalculatedDistance:
+			epoch.Distance = v.Distance
+			loggerDistance = true
 		case *GPSAltitude:
 			epoch.Altitude = v.Altitude
 			epoch.AltitudeAccuracy = v.Accuracy
 		case *StartStopInfo:
 			epoch.StartStopInfo = *v
+			err = send(ctx, output, sample)
+		case *ZAcceleration:
+			epoch.VerticalAcceleration = v.Vertical
+		case *YawRate:
+			epoch.YawRate = v.Rate
+		case *CalculatedYaw:
+			epoch.Yaw = v.Yaw
+		case *PitchRate:
+			epoch.PitchRate = v.Rate
+		case *PitchAngle:
+			epoch.Pitch = v.Angle
+		case *RollRate:
+			epoch.RollRate = v.Rate
+		case *RollAngle:
+			epoch.Roll = v.Angle
+		case *Gradient:
+			epoch.Gradient = v.Gradient
+			epoch.GradientAccuracy = v.Accuracy
 		default:
-			output <- sample
+			err = send(ctx, output, sample)
+		}
+
+		if err != nil {
+			break
 		}
 	}
-	close(output)
+	return err
 }
@@ -15,10 +15,30 @@ type Epoch struct {
 	// Stop is the ending offset for this Epoch
 	Stop TimeOffset
 
+	// Lap is the number of the lap that the Epoch was recorded in.  Epochs
+	// recorded before the first crossing of the Start/Finish lap marker
+	// are in lap 0.  Lap is assigned by the SectorAnalyzer
+	Lap int
+
+	// LapTime is the elapsed time since the vehicle crossed the Start/Finish
+	// lap marker.  LapTime is assigned by the SectorAnalyzer
+	LapTime TimeOffset
+
+	// Distance is the distance (in meters) travelled since the start of the
+	// run.  Distance is calculated by the SampleDemuxer unless the data
+	// logger reports a pre-calculated distance
+	Distance Distance
+
+	// LapDistance is the distance (in meters) travelled since the vehicle
+	// crossed the Start/Finish lap marker.  LapDistance is assigned by the
+	// SectorAnalyzer
+	LapDistance Distance
+
 	// TimeSlip indicates whether the vehicle is going faster
 	// or slower at ta given point.  TimeSlip is a cumulative
-	// value and is reset at the Start/Finish lap marker.
-	// TimeSlip is calculated by the LapAnalyzer and is not a measured
+	// value and is reset at the Start/Finish lap marker. Positive
+	// values indicate the vehicle is behind the reference lap.
+	// TimeSlip is calculated by the TimeSlipAnalyzer and is not a measured
 	// value
 	TimeSlip TimeOffset
 
@@ -45,6 +65,34 @@ type Epoch struct {
 	// and longitudinal acceleration.
 	VectorAcceleration Acceleration
 
+	// VerticalAcceleration is the current vertical acceleration in G
+	// as measured by the IMU
+	VerticalAcceleration Acceleration
+
+	// YawRate is the rate of rotation (in degrees per second) around the
+	// vertical axis of the vehicle
+	YawRate AngularRate
+
+	// Yaw is the yaw angle (in degrees) calculated by the IMU
+	Yaw Angle
+
+	// PitchRate is the rate of rotation (in degrees per second) around the
+	// lateral axis of the vehicle
+	PitchRate AngularRate
+
+	// Pitch is the angle (in degrees) of the vehicle around its lateral axis
+	Pitch Angle
+
+	// RollRate is the rate of rotation (in degrees per second) around the
+	// longitudinal axis of the vehicle
+	RollRate AngularRate
+
+	// Roll is the angle (in degrees) of the vehicle around its longitudinal axis
+	Roll Angle
+
+	// Gradient is the slope (in degrees) of the surface the vehicle is on
+	Gradient Angle
+
 	// Heading is the direction (in degrees) that the vehicle is moving
 	Heading Heading
 
@@ -60,9 +108,17 @@ type Epoch struct {
 	// AnalogInputs is a map containing the voltages of reported analog inputs
 	AnalogInputs map[Channel]Voltage
 
-	// FrequencyInputs is a map containing the frequency (in hertz) of reported frequency inputs
+	// FrequencyInputs is a map containing the frequency (in hertz) of reported frequency inputs,
+	// including the extended frequency inputs
 	FrequencyInputs map[Channel]Frequency
 
+	// EngineSpeed is the current engine speed in revolutions per minute
+	EngineSpeed EngineSpeed
+
+	// Measurements are the named values calculated from the logged data,
+	// such as calibrated sensor readings
+	Measurements map[string]Measurement
+
 	// HeadingAccuracy indidcates the accuracy of the heading in degrees
 	HeadingAccuracy HeadingAccuracy
 
@@ -71,6 +127,9 @@ type Epoch struct {
 
 	// GPSAccuracy indicates the accuracy of the latitude and longitude in millimeters
 	GPSAccuracy GPSAccuracy
+
+	// GradientAccuracy indicates the accuracy of the gradient in degrees
+	GradientAccuracy Angle
 }
 
 // Type returns the Sample Type, in this case "Epoch"
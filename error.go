@@ -17,12 +17,30 @@ var (
 	// input data channel
 	ErrUnknownLength = fmt.Errorf("Unknown message length")
 
+	// ErrUnsynchronized indicates a frame that was rejected, despite a
+	// correct checksum, because the frame following it was not good
+	ErrUnsynchronized = fmt.Errorf("Frame is not followed by a good frame")
+
 	// ErrEOF indicates no more data is available for reading
 	ErrEOF = io.EOF
 
 	// ErrUnexpectedInput indicates an unknown or otherwise unexpected value
 	// was received in a byte stream
 	ErrUnexpectedInput = fmt.Errorf("Unexpected Input")
+
+	// ErrNotMarshalable indicates a sample that cannot be encoded into a
+	// data frame, such as an Epoch
+	ErrNotMarshalable = fmt.Errorf("Sample cannot be marshaled")
+
+	// ErrInvalidRate indicates a sample rate that is not greater than zero
+	ErrInvalidRate = fmt.Errorf("Sample rate must be greater than zero")
+
+	// ErrInvalidStep indicates a distance step that is not greater than zero
+	ErrInvalidStep = fmt.Errorf("Distance step must be greater than zero")
+
+	// ErrNoLaps indicates that there were no laps, or no recorded epochs
+	// within a lap, to compare
+	ErrNoLaps = fmt.Errorf("No laps to compare")
 )
 
 // BufError has information to indicate a buffer error
@@ -45,13 +63,24 @@ func (be *BufError) Error() string {
 	return fmt.Sprintf("%sneed %d bytes got %d", cause, be.Need, be.Got)
 }
 
+// Unwrap returns the underlying cause of the buffer error
+func (be *BufError) Unwrap() error {
+	return be.Cause
+}
+
 // ParseError indicates a failure in parsing a byte buffer
 type ParseError struct {
 	reason string
+	cause  error
 }
 
 func newParseError(reason string) error {
-	return &ParseError{reason}
+	return &ParseError{reason: reason}
+}
+
+// wrapParseError returns a ParseError that was caused by another error
+func wrapParseError(cause error, reason string) error {
+	return &ParseError{reason: reason, cause: cause}
 }
 
 // Error returns the reason for the parse error
@@ -59,6 +88,41 @@ func (pe *ParseError) Error() string {
 	return pe.reason
 }
 
+// Unwrap returns the underlying cause of the parse error, if any
+func (pe *ParseError) Unwrap() error {
+	return pe.cause
+}
+
+// FrameError indicates a failure to read or decode a single data frame. The
+// underlying cause is available with errors.Is and errors.As
+type FrameError struct {
+	// Offset is the byte offset of the frame in the input
+	Offset int64
+
+	// Channel is the data channel of the frame
+	Channel Channel
+
+	// Data is the raw bytes of the frame, as many as were available
+	Data []byte
+
+	// Err is the underlying error
+	Err error
+}
+
+func newFrameError(offset int64, channel Channel, data []byte, err error) *FrameError {
+	return &FrameError{Offset: offset, Channel: channel, Data: data, Err: err}
+}
+
+// Error returns the underlying error along with the frame's position
+func (fe *FrameError) Error() string {
+	return fmt.Sprintf("%v: %v frame at offset %d [%s]", fe.Err, fe.Channel, fe.Offset, hexDump(fe.Data))
+}
+
+// Unwrap returns the underlying error
+func (fe *FrameError) Unwrap() error {
+	return fe.Err
+}
+
 func checkBufLen(buf []byte, need int) error {
 	if len(buf) < need {
 		return newBufError(ErrShortBuffer, need, len(buf))
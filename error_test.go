@@ -0,0 +1,41 @@
+package dl
+
+import (
+	"errors"
+	"go/scanner"
+	"testing"
+)
+
+func TestErrorUnwrap(t *testing.T) {
+	// a RunStatusChannel message with an unknown start method
+	message := newMessage(RunStatusChannel, []byte{0x03, 0, 0, 0, 0, 0, 0, 0})
+	message.Offset = 42
+	_, err := message.Decode()
+
+	var frameErr *FrameError
+	if !errors.As(err, &frameErr) || frameErr.Offset != 42 || frameErr.Channel != RunStatusChannel {
+		t.Fatalf("Expected a FrameError for the frame at offset 42 got %v", err)
+	}
+
+	var bufErr *BufError
+	if !errors.As(err, &bufErr) || !errors.Is(err, ErrShortBuffer) {
+		t.Errorf("Expected the FrameError to wrap a short BufError got %v", frameErr.Err)
+	}
+
+	_, err = ParseChannel("Analog 99")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || errors.Unwrap(parseErr) != nil {
+		t.Errorf("Expected a ParseError with no cause got %v", err)
+	}
+
+	_, err = NewMathChannel("broken", "", "Speed +")
+	var syntaxErr scanner.ErrorList
+	if !errors.As(err, &parseErr) || !errors.As(err, &syntaxErr) {
+		t.Errorf("Expected a ParseError wrapping the syntax error got %v", err)
+	}
+
+	decodeErr := &DecodeError{Message: message, Err: err}
+	if !errors.Is(decodeErr, err) {
+		t.Errorf("Expected the DecodeError to wrap %v", err)
+	}
+}
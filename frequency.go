@@ -0,0 +1,107 @@
+package dl
+
+import (
+	"math"
+)
+
+// ExtendedFrequencyInput contains information sent on data channels
+// 58-61 representing measured values on the extended frequency inputs
+// of the data logger.  The extended inputs have a 32 bit period counter
+// and can measure much lower frequencies than the standard frequency
+// inputs
+type ExtendedFrequencyInput struct {
+	// Channel that was reported (ExtendedFrequencyChannel1-ExtendedFrequencyChannel4)
+	Channel Channel
+
+	// Frequency reported in hertz
+	Frequency Frequency
+
+	// raw is the data the input was decoded from
+	raw []byte
+}
+
+// Type returns the Sample Type, in this case "ExtendedFrequencyInput"
+func (*ExtendedFrequencyInput) Type() string { return "ExtendedFrequencyInput" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the ExtendedFrequencyInput. BufError is returned
+// if the input buffer is too short to process
+func (freq *ExtendedFrequencyInput) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 9)
+	if err == nil {
+		// number of 6 Mhz pulses received for the input signal
+		value := int64(buf[0])<<24 | int64(buf[1])<<16 | int64(buf[2])<<8 | int64(buf[3])
+
+		// time (in seconds) taken for input signal
+		time := float64(value) * 1.66666666666667e-07
+
+		// frequency (in herz - 1s / period)
+		freq.Frequency = 0
+		if time > 0 {
+			freq.Frequency = Frequency(1 / time)
+		}
+		freq.raw = append([]byte(nil), buf...)
+	}
+	return err
+}
+
+// DataChannel returns the data channel that the ExtendedFrequencyInput
+// was reported on
+func (freq *ExtendedFrequencyInput) DataChannel() Channel { return freq.Channel }
+
+// MarshalBinary encodes the ExtendedFrequencyInput into the data portion of
+// an ExtendedFrequencyChannel1-ExtendedFrequencyChannel4 message.  The
+// trailing bytes that are not decoded are written back as they were received
+func (freq *ExtendedFrequencyInput) MarshalBinary() ([]byte, error) {
+	buf := undecoded(freq.raw, 9)
+	copy(buf, encodeUint32(periodPulses(float64(freq.Frequency))))
+	return buf, nil
+}
+
+// RPM contains the engine speed received on the Extended RPM channel
+// (data channel 62).  The engine speed is measured as the period of one
+// revolution of the engine
+type RPM struct {
+	// Speed is the engine speed in revolutions per minute
+	Speed EngineSpeed
+
+	// raw is the data the engine speed was decoded from
+	raw []byte
+}
+
+// Type returns the Sample Type, in this case "RPM"
+func (*RPM) Type() string { return "RPM" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the RPM. BufError is returned
+// if the input buffer is too short to process
+func (rpm *RPM) UnmarshalBinary(buf []byte) error {
+	freq := &ExtendedFrequencyInput{}
+	err := freq.UnmarshalBinary(buf)
+	if err == nil {
+		rpm.Speed = EngineSpeed(freq.Frequency * 60)
+		rpm.raw = freq.raw
+	}
+	return err
+}
+
+// DataChannel returns the data channel for RPM (ExtendedRPMChannel)
+func (*RPM) DataChannel() Channel { return ExtendedRPMChannel }
+
+// MarshalBinary encodes the RPM into the data portion of an
+// ExtendedRPMChannel message.  The trailing bytes that are not decoded
+// are written back as they were received
+func (rpm *RPM) MarshalBinary() ([]byte, error) {
+	buf := undecoded(rpm.raw, 9)
+	copy(buf, encodeUint32(periodPulses(float64(rpm.Speed)/60)))
+	return buf, nil
+}
+
+// periodPulses returns the number of 6 Mhz pulses in one period of
+// the given frequency
+func periodPulses(frequency float64) uint32 {
+	if frequency <= 0 {
+		return 0
+	}
+	return uint32(math.Round(1 / (frequency * 1.66666666666667e-07)))
+}
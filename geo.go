@@ -0,0 +1,34 @@
+package dl
+
+import (
+	"math"
+)
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius = 6371008.8
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// greatCircle computes the distance (in meters) between two points on the
+// surface of the earth using the haversine formula
+func greatCircle(lat1, lon1, lat2, lon2 Coordinate) float64 {
+	phi1 := radians(float64(lat1))
+	phi2 := radians(float64(lat2))
+	dPhi := phi2 - phi1
+	dLambda := radians(float64(lon2 - lon1))
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// localOffset projects a point onto a flat plane centered at the origin
+// coordinates.  The returned x (east) and y (north) values are in meters.
+// The projection is only accurate for points that are close to the origin,
+// which is the case for anything on a single race track
+func localOffset(originLat, originLon, lat, lon Coordinate) (x, y float64) {
+	x = radians(float64(lon-originLon)) * math.Cos(radians(float64(originLat))) * earthRadius
+	y = radians(float64(lat-originLat)) * earthRadius
+	return x, y
+}
@@ -0,0 +1,110 @@
+package dl
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const gpxHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="github.com/abates/dl" xmlns="http://www.topografix.com/GPX/1/1" xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v2">
+<trk>
+<trkseg>
+`
+
+const gpxFooter = `</trkseg>
+</trk>
+</gpx>
+`
+
+type gpxPoint struct {
+	XMLName   xml.Name `xml:"trkpt"`
+	Latitude  float64  `xml:"lat,attr"`
+	Longitude float64  `xml:"lon,attr"`
+	Elevation float64  `xml:"ele"`
+	Time      string   `xml:"time,omitempty"`
+	Speed     float64  `xml:"extensions>gpxtpx:TrackPointExtension>gpxtpx:speed"`
+}
+
+// epochClock computes the wall clock time of each epoch.  The data logger
+// only reports the date and time periodically, so the time of an epoch is
+// the last reported time plus the time elapsed since it was reported
+type epochClock struct {
+	time   time.Time
+	offset TimeOffset
+}
+
+func (ec *epochClock) at(epoch *Epoch) time.Time {
+	if !epoch.Time.Equal(ec.time) {
+		ec.time = epoch.Time
+		ec.offset = epoch.Stop
+	}
+
+	if ec.time.IsZero() {
+		return ec.time
+	}
+	return ec.time.Add(time.Duration(epoch.Stop-ec.offset) * time.Millisecond)
+}
+
+// hasPosition indicates whether the epoch contains a GPS position
+func hasPosition(epoch *Epoch) bool {
+	return epoch.Latitude != 0 || epoch.Longitude != 0
+}
+
+// GPXAnalyzer writes the position of every Epoch as a GPX 1.1 track point.
+// The elevation of each point is taken from the GPS altitude and the speed
+// is written using the Garmin TrackPointExtension.  All input samples are
+// passed to the output
+type GPXAnalyzer struct {
+	writer io.Writer
+}
+
+// NewGPXAnalyzer returns a GPXAnalyzer that writes to the given writer
+func NewGPXAnalyzer(writer io.Writer) *GPXAnalyzer {
+	return &GPXAnalyzer{writer: writer}
+}
+
+// Process will start the GPX loop.  This should usually be run in
+// a go routine
+func (ga *GPXAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	_, err := io.WriteString(ga.writer, gpxHeader)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(ga.writer)
+	clock := &epochClock{}
+	for sample := range input {
+		if epoch, ok := sample.(*Epoch); ok {
+			t := clock.at(epoch)
+			if hasPosition(epoch) {
+				point := &gpxPoint{
+					Latitude:  float64(epoch.Latitude),
+					Longitude: float64(epoch.Longitude),
+					Elevation: float64(epoch.Altitude) / 1000,
+					Speed:     float64(epoch.Speed),
+				}
+
+				if !t.IsZero() {
+					point.Time = t.UTC().Format("2006-01-02T15:04:05.000Z")
+				}
+
+				if err = encoder.Encode(point); err == nil {
+					_, err = io.WriteString(ga.writer, "\n")
+				}
+
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if err = send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+
+	_, err = io.WriteString(ga.writer, gpxFooter)
+	return err
+}
@@ -0,0 +1,55 @@
+package dl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestGPXAnalyzer(t *testing.T) {
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	samples := []Sample{
+		&Epoch{Stop: 100, Time: start, Latitude: 40.5, Longitude: -75.25, Altitude: 123500, Speed: 12.5},
+		&Epoch{Stop: 200, Time: start},
+		&Epoch{Stop: 300, Time: start, Latitude: -33.75, Longitude: 151.125, Altitude: -2000, Speed: 20},
+		&Epoch{Stop: 400, Latitude: 1, Longitude: 2},
+	}
+
+	buf := &bytes.Buffer{}
+	runAnalyzers(t, samples, NewGPXAnalyzer(buf))
+
+	var gpx struct {
+		Points []struct {
+			Latitude  float64 `xml:"lat,attr"`
+			Longitude float64 `xml:"lon,attr"`
+			Elevation float64 `xml:"ele"`
+			Time      string  `xml:"time"`
+			Speed     float64 `xml:"extensions>TrackPointExtension>speed"`
+		} `xml:"trk>trkseg>trkpt"`
+	}
+
+	if err := xml.Unmarshal(buf.Bytes(), &gpx); err != nil {
+		t.Fatalf("Unmarshal() returned %v", err)
+	}
+
+	tests := []struct {
+		latitude, longitude, elevation, speed float64
+		time                                  string
+	}{
+		{40.5, -75.25, 123.5, 12.5, "2018-06-01T12:00:00.000Z"},
+		{-33.75, 151.125, -2, 20, "2018-06-01T12:00:00.200Z"},
+		{1, 2, 0, 0, ""},
+	}
+
+	if len(gpx.Points) != len(tests) {
+		t.Fatalf("expected %d points got %d", len(tests), len(gpx.Points))
+	}
+
+	for i, test := range tests {
+		point := gpx.Points[i]
+		if point.Latitude != test.latitude || point.Longitude != test.longitude || point.Elevation != test.elevation || point.Speed != test.speed || point.Time != test.time {
+			t.Errorf("point %d: expected %+v got %+v", i, test, point)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package dl
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// sliceSource is a Source that produces a fixed set of samples
+type sliceSource struct {
+	samples []Sample
+	output  chan Sample
+}
+
+func newSliceSource(samples ...Sample) *sliceSource {
+	return &sliceSource{samples: samples, output: make(chan Sample)}
+}
+
+func (ss *sliceSource) Read(ctx context.Context) error {
+	defer close(ss.output)
+	for _, sample := range ss.samples {
+		if err := send(ctx, ss.output, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ss *sliceSource) Output() <-chan Sample { return ss.output }
+
+// runAnalyzers runs the samples through a chain of analyzers and returns
+// everything that came out of the end of the chain
+func runAnalyzers(t *testing.T, samples []Sample, analyzers ...Analyzer) []Sample {
+	t.Helper()
+	chain := NewProcessingChain(context.Background(), newSliceSource(samples...))
+	for _, analyzer := range analyzers {
+		chain.Append(analyzer)
+	}
+
+	var output []Sample
+	for sample := range chain.Output() {
+		output = append(output, sample)
+	}
+
+	if err := chain.Wait(); err != nil {
+		t.Fatalf("Wait() returned %v", err)
+	}
+	return output
+}
+
+// testOrigin is the position that test tracks are laid out around
+const testOriginLat, testOriginLon = Coordinate(40), Coordinate(-75)
+
+// position converts an offset (in meters) east and north of the test origin
+// to a latitude and longitude
+func position(x, y float64) (Coordinate, Coordinate) {
+	lat := float64(testOriginLat) + y/earthRadius*180/math.Pi
+	lon := float64(testOriginLon) + x/(earthRadius*math.Cos(radians(float64(testOriginLat))))*180/math.Pi
+	return Coordinate(lat), Coordinate(lon)
+}
+
+// positionEpoch returns an Epoch ending at stop with the position x meters
+// east and y meters north of the test origin
+func positionEpoch(stop TimeOffset, x, y float64, distance Distance) *Epoch {
+	lat, lon := position(x, y)
+	return &Epoch{Start: stop - 100, Stop: stop, Latitude: lat, Longitude: lon, Distance: distance}
+}
+
+// circleRadius is the radius (in meters) of the circular test track
+const circleRadius = 100.0
+
+// circleLapTime is the time taken to drive one lap of the test track, at
+// one Epoch every 100 ms
+const circleLapTime = 10000
+
+// circleMarker returns a lap marker on the circular test track at the given
+// angle (in radians, clockwise from north) pointing in the direction of travel
+func circleMarker(number int, angle float64) *LapMarker {
+	lat, lon := position(circleRadius*math.Sin(angle), circleRadius*math.Cos(angle))
+	return &LapMarker{Marker: number, Latitude: lat, Longitude: lon, Heading: Heading(math.Mod(angle*180/math.Pi+90, 360))}
+}
+
+// circleEpochs drives clockwise around the circular test track at a
+// constant speed, starting just before the northern most point
+func circleEpochs(laps int) []Sample {
+	var samples []Sample
+	steps := circleLapTime / 100
+	for i := 0; i <= laps*steps+10; i++ {
+		angle := 2 * math.Pi * float64(i-5) / float64(steps)
+		distance := Distance(circleRadius * 2 * math.Pi * float64(i) / float64(steps))
+		samples = append(samples, positionEpoch(TimeOffset(i*100), circleRadius*math.Sin(angle), circleRadius*math.Cos(angle), distance))
+	}
+	return samples
+}
@@ -0,0 +1,252 @@
+package dl
+
+// YawRate contains the rate of rotation around the vertical axis
+// of the vehicle (data channel 79)
+type YawRate struct {
+	// Rate is the yaw rate in degrees per second. Positive values
+	// indicate clockwise rotation
+	Rate AngularRate
+}
+
+// Type returns the Sample Type, in this case "YawRate"
+func (*YawRate) Type() string { return "YawRate" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the YawRate. BufError is returned
+// if the input buffer is too short to process
+func (yaw *YawRate) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 2)
+	if err == nil {
+		yaw.Rate = AngularRate(computeSigned(buf[0:2])) * 0.01
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a YawRate (YawRatesChannel)
+func (*YawRate) DataChannel() Channel { return YawRatesChannel }
+
+// MarshalBinary encodes the YawRate into the data portion of a
+// YawRatesChannel message
+func (yaw *YawRate) MarshalBinary() ([]byte, error) {
+	return encodeSigned(float64(yaw.Rate)/0.01, 2), nil
+}
+
+// CalculatedYaw contains the yaw angle computed by the IMU from the yaw
+// rate (data channel 80)
+type CalculatedYaw struct {
+	// Yaw is the yaw angle in degrees (0-360)
+	Yaw Angle
+}
+
+// Type returns the Sample Type, in this case "CalculatedYaw"
+func (*CalculatedYaw) Type() string { return "CalculatedYaw" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the CalculatedYaw. BufError is returned
+// if the input buffer is too short to process
+func (yaw *CalculatedYaw) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 2)
+	if err == nil {
+		yaw.Yaw = Angle(int(buf[0])<<8|int(buf[1])) * 0.01
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a CalculatedYaw (CalculatedYawChannel)
+func (*CalculatedYaw) DataChannel() Channel { return CalculatedYawChannel }
+
+// MarshalBinary encodes the CalculatedYaw into the data portion of a
+// CalculatedYawChannel message
+func (yaw *CalculatedYaw) MarshalBinary() ([]byte, error) {
+	return encodeSigned(float64(yaw.Yaw)/0.01, 2), nil
+}
+
+// PitchRate contains the rate of rotation around the lateral axis
+// of the vehicle (data channel 81)
+type PitchRate struct {
+	// Rate is the pitch rate in degrees per second. Positive values
+	// indicate the nose of the vehicle is rising
+	Rate AngularRate
+}
+
+// Type returns the Sample Type, in this case "PitchRate"
+func (*PitchRate) Type() string { return "PitchRate" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the PitchRate. BufError is returned
+// if the input buffer is too short to process
+func (pitch *PitchRate) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 3)
+	if err == nil {
+		pitch.Rate = AngularRate(computeSigned(buf[0:3])) * 0.001
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a PitchRate (PitchRateChannel)
+func (*PitchRate) DataChannel() Channel { return PitchRateChannel }
+
+// MarshalBinary encodes the PitchRate into the data portion of a
+// PitchRateChannel message
+func (pitch *PitchRate) MarshalBinary() ([]byte, error) {
+	return encodeSigned(float64(pitch.Rate)/0.001, 3), nil
+}
+
+// PitchAngle contains the angle of the vehicle around its lateral
+// axis (data channel 82)
+type PitchAngle struct {
+	// Angle is the pitch angle in degrees. Positive values indicate
+	// the nose of the vehicle is up
+	Angle Angle
+}
+
+// Type returns the Sample Type, in this case "PitchAngle"
+func (*PitchAngle) Type() string { return "PitchAngle" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the PitchAngle. BufError is returned
+// if the input buffer is too short to process
+func (pitch *PitchAngle) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 3)
+	if err == nil {
+		pitch.Angle = Angle(computeSigned(buf[0:3])) * 0.001
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a PitchAngle (PitchAngleChannel)
+func (*PitchAngle) DataChannel() Channel { return PitchAngleChannel }
+
+// MarshalBinary encodes the PitchAngle into the data portion of a
+// PitchAngleChannel message
+func (pitch *PitchAngle) MarshalBinary() ([]byte, error) {
+	return encodeSigned(float64(pitch.Angle)/0.001, 3), nil
+}
+
+// RollRate contains the rate of rotation around the longitudinal axis
+// of the vehicle (data channel 83)
+type RollRate struct {
+	// Rate is the roll rate in degrees per second. Positive values
+	// indicate the vehicle is rolling to the right
+	Rate AngularRate
+}
+
+// Type returns the Sample Type, in this case "RollRate"
+func (*RollRate) Type() string { return "RollRate" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the RollRate. BufError is returned
+// if the input buffer is too short to process
+func (roll *RollRate) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 3)
+	if err == nil {
+		roll.Rate = AngularRate(computeSigned(buf[0:3])) * 0.001
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a RollRate (RollRateChannel)
+func (*RollRate) DataChannel() Channel { return RollRateChannel }
+
+// MarshalBinary encodes the RollRate into the data portion of a
+// RollRateChannel message
+func (roll *RollRate) MarshalBinary() ([]byte, error) {
+	return encodeSigned(float64(roll.Rate)/0.001, 3), nil
+}
+
+// RollAngle contains the angle of the vehicle around its longitudinal
+// axis (data channel 84)
+type RollAngle struct {
+	// Angle is the roll angle in degrees. Positive values indicate
+	// the vehicle is leaning to the right
+	Angle Angle
+}
+
+// Type returns the Sample Type, in this case "RollAngle"
+func (*RollAngle) Type() string { return "RollAngle" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the RollAngle. BufError is returned
+// if the input buffer is too short to process
+func (roll *RollAngle) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 3)
+	if err == nil {
+		roll.Angle = Angle(computeSigned(buf[0:3])) * 0.001
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a RollAngle (RollAngleChannel)
+func (*RollAngle) DataChannel() Channel { return RollAngleChannel }
+
+// MarshalBinary encodes the RollAngle into the data portion of a
+// RollAngleChannel message
+func (roll *RollAngle) MarshalBinary() ([]byte, error) {
+	return encodeSigned(float64(roll.Angle)/0.001, 3), nil
+}
+
+// Gradient contains the slope of the road surface the vehicle is
+// travelling on (data channel 85)
+type Gradient struct {
+	// Gradient is the slope in degrees. Positive values indicate the
+	// vehicle is travelling uphill
+	Gradient Angle
+
+	// Accuracy is the accuracy of the gradient measurement in degrees
+	Accuracy Angle
+}
+
+// Type returns the Sample Type, in this case "Gradient"
+func (*Gradient) Type() string { return "Gradient" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the Gradient. BufError is returned
+// if the input buffer is too short to process
+func (gradient *Gradient) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 8)
+	if err == nil {
+		gradient.Gradient = Angle(computeGeo(buf[0:4])) * 0.00001
+		gradient.Accuracy = Angle(computeGeo(buf[4:8])) * 0.00001
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a Gradient (GradientChannel)
+func (*Gradient) DataChannel() Channel { return GradientChannel }
+
+// MarshalBinary encodes the Gradient into the data portion of a
+// GradientChannel message
+func (gradient *Gradient) MarshalBinary() ([]byte, error) {
+	buf := encodeGeo(float64(gradient.Gradient) / 0.00001)
+	return append(buf, encodeGeo(float64(gradient.Accuracy)/0.00001)...), nil
+}
+
+// ZAcceleration contains the vertical accelerometer data received on
+// data channel 92
+type ZAcceleration struct {
+	// Vertical acceleration in G
+	Vertical Acceleration
+}
+
+// Type returns the Sample Type, in this case "ZAcceleration"
+func (*ZAcceleration) Type() string { return "ZAcceleration" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the ZAcceleration. BufError is returned
+// if the input buffer is too short to process
+func (accel *ZAcceleration) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 2)
+	if err == nil {
+		accel.Vertical = computeAcceleration(buf[0:2])
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a ZAcceleration (ZAccelerationChannel)
+func (*ZAcceleration) DataChannel() Channel { return ZAccelerationChannel }
+
+// MarshalBinary encodes the ZAcceleration into the data portion of a
+// ZAccelerationChannel message
+func (accel *ZAcceleration) MarshalBinary() ([]byte, error) {
+	return encodeAcceleration(accel.Vertical), nil
+}
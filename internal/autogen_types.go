@@ -34,6 +34,10 @@ var unitTypes = []unitType{
 	{"Frequency", "freq", "is a measurement sampled from the frequency inputs of the data logger", "float64", "hertz", "hz"},
 	{"Altitude", "altitude", "is the height above sea level as measured by GPS", "int", "millimeters", "mm"},
 	{"AltitudeAccuracy", "accuracy", "is the accuracy of the altitude measurement", "int", "millimeters", "mm"},
+	{"AngularRate", "rate", "is the rate of rotation around one of the vehicle's axes", "float64", "degrees per second", "°/s"},
+	{"EngineSpeed", "speed", "is the rotational speed of the engine", "float64", "revolutions per minute", "rpm"},
+	{"Angle", "angle", "is the rotation around one of the vehicle's axes", "float64", "degrees", "°"},
+	{"Distance", "dist", "is the distance travelled by the vehicle", "float64", "meters", "m"},
 }
 
 const typeTemplate = `
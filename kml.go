@@ -0,0 +1,84 @@
+package dl
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPlacemark struct {
+	Name       string        `xml:"name"`
+	LineString kmlLineString `xml:"LineString"`
+}
+
+type kmlDocument struct {
+	XMLName    xml.Name       `xml:"kml"`
+	Namespace  string         `xml:"xmlns,attr"`
+	Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+}
+
+func newKMLPlacemark(name string, epochs []*Epoch) kmlPlacemark {
+	coordinates := make([]string, 0, len(epochs))
+	for _, epoch := range epochs {
+		if hasPosition(epoch) {
+			coordinates = append(coordinates, fmt.Sprintf("%s,%s,%s", formatFloat(float64(epoch.Longitude)), formatFloat(float64(epoch.Latitude)), formatFloat(float64(epoch.Altitude)/1000)))
+		}
+	}
+	return kmlPlacemark{
+		Name:       name,
+		LineString: kmlLineString{Tessellate: 1, Coordinates: strings.Join(coordinates, " ")},
+	}
+}
+
+// KMLAnalyzer writes a KML document containing one LineString for each
+// Lap produced by a SectorAnalyzer.  If no laps were detected then a single
+// LineString for the whole session is written.  The document is written
+// once the input is closed.  All input samples are passed to the output
+type KMLAnalyzer struct {
+	writer io.Writer
+}
+
+// NewKMLAnalyzer returns a KMLAnalyzer that writes to the given writer
+func NewKMLAnalyzer(writer io.Writer) *KMLAnalyzer {
+	return &KMLAnalyzer{writer: writer}
+}
+
+// Process will start the KML loop.  This should usually be run in
+// a go routine
+func (ka *KMLAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	document := &kmlDocument{Namespace: "http://www.opengis.net/kml/2.2"}
+	var epochs []*Epoch
+
+	for sample := range input {
+		switch v := sample.(type) {
+		case *Epoch:
+			epochs = append(epochs, v)
+		case *Lap:
+			name := fmt.Sprintf("Lap %d (%.3fs)", v.Number, float64(v.Elapsed())/1000)
+			document.Placemarks = append(document.Placemarks, newKMLPlacemark(name, v.Epochs))
+		}
+
+		if err := send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+
+	if len(document.Placemarks) == 0 {
+		document.Placemarks = append(document.Placemarks, newKMLPlacemark("Session", epochs))
+	}
+
+	_, err := io.WriteString(ka.writer, xml.Header)
+	if err == nil {
+		encoder := xml.NewEncoder(ka.writer)
+		encoder.Indent("", "  ")
+		err = encoder.Encode(document)
+	}
+	return err
+}
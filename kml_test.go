@@ -0,0 +1,73 @@
+package dl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKMLAnalyzer(t *testing.T) {
+	epochs := []*Epoch{
+		{Stop: 100, Latitude: 40.5, Longitude: -75.25, Altitude: 123500},
+		{Stop: 200},
+		{Stop: 300, Latitude: -33.75, Longitude: 151.125, Altitude: -2000},
+		{Stop: 400, Latitude: 1, Longitude: 2},
+	}
+
+	tests := []struct {
+		name       string
+		laps       []*Lap
+		placemarks []string
+		points     [][]string
+	}{
+		{"session", nil, []string{"Session"}, [][]string{{"-75.25,40.5,123.5", "151.125,-33.75,-2", "2,1,0"}}},
+		{
+			"laps",
+			[]*Lap{
+				{Number: 1, Start: 0, Stop: 61500, Epochs: epochs[:2]},
+				{Number: 2, Start: 61500, Stop: 122750, Epochs: epochs[2:]},
+			},
+			[]string{"Lap 1 (61.500s)", "Lap 2 (61.250s)"},
+			[][]string{{"-75.25,40.5,123.5"}, {"151.125,-33.75,-2", "2,1,0"}},
+		},
+	}
+
+	for _, test := range tests {
+		var samples []Sample
+		for _, epoch := range epochs {
+			samples = append(samples, epoch)
+		}
+
+		for _, lap := range test.laps {
+			samples = append(samples, lap)
+		}
+
+		buf := &bytes.Buffer{}
+		runAnalyzers(t, samples, NewKMLAnalyzer(buf))
+
+		var kml struct {
+			Placemarks []struct {
+				Name        string `xml:"name"`
+				Coordinates string `xml:"LineString>coordinates"`
+			} `xml:"Document>Placemark"`
+		}
+
+		if err := xml.Unmarshal(buf.Bytes(), &kml); err != nil {
+			t.Errorf("%s: Unmarshal() returned %v", test.name, err)
+			continue
+		}
+
+		var placemarks []string
+		var points [][]string
+		for _, placemark := range kml.Placemarks {
+			placemarks = append(placemarks, placemark.Name)
+			points = append(points, strings.Fields(placemark.Coordinates))
+		}
+
+		if !reflect.DeepEqual(placemarks, test.placemarks) || !reflect.DeepEqual(points, test.points) {
+			t.Errorf("%s: expected %v %v got %v %v", test.name, test.placemarks, test.points, placemarks, points)
+		}
+	}
+}
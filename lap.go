@@ -1,32 +1,307 @@
 package dl
 
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// DefaultMarkerWidth is the distance (in meters) to either side of a lap
+// marker that the vehicle must pass within for the marker to be crossed
+const DefaultMarkerWidth = 25.0
+
+// loggerTimeWindow is how long (in milliseconds) after a marker is crossed
+// that the SectorAnalyzer waits for the data logger to report its own time
+// for the sector
+const loggerTimeWindow = TimeOffset(1000)
+
+// Sector contains the epochs recorded between two consecutive lap markers
 type Sector struct {
-	epochs []Epoch
+	// Number is the marker number of the lap marker that opened the
+	// sector.  The first sector of every lap starts at the start/finish
+	// line, the marker with the lowest marker number
+	Number int
+
+	// Lap is the number of the lap that the sector belongs to
+	Lap int
+
+	// Start is the offset at which the vehicle crossed the opening marker
+	Start TimeOffset
+
+	// Stop is the offset at which the vehicle crossed the closing marker
+	Stop TimeOffset
+
+	// Epochs are the epochs recorded within the sector
+	Epochs []*Epoch
+
+	// LoggerTime is the sector time reported by the data logger for the
+	// marker that closed the sector.  LoggerTime is nil if the data logger
+	// did not report a sector time
+	LoggerTime *SectorTime
 }
 
+// Type returns the Sample Type, in this case "Sector"
+func (*Sector) Type() string { return "Sector" }
+
+// Elapsed returns the time taken to complete the sector
+func (sector *Sector) Elapsed() TimeOffset { return sector.Stop - sector.Start }
+
+// Lap contains the sectors and epochs recorded between two consecutive
+// crossings of the start/finish line
+type Lap struct {
+	// Number is the lap number, starting with 1 for the first
+	// complete lap in the session
+	Number int
+
+	// Start is the offset at which the vehicle crossed the start/finish line
+	// to begin the lap
+	Start TimeOffset
+
+	// Stop is the offset at which the vehicle crossed the start/finish line
+	// to end the lap
+	Stop TimeOffset
+
+	// Sectors are the sectors completed during the lap
+	Sectors []*Sector
+
+	// Epochs are all the epochs recorded during the lap
+	Epochs []*Epoch
+}
+
+// Type returns the Sample Type, in this case "Lap"
+func (*Lap) Type() string { return "Lap" }
+
+// Elapsed returns the lap time
+func (lap *Lap) Elapsed() TimeOffset { return lap.Stop - lap.Start }
+
+// SectorInfo is the set of lap markers that divide a track into
+// sectors.  The marker with the lowest marker number is considered
+// the start/finish line
 type SectorInfo struct {
 	markers []LapMarker
 }
 
+// AddMarker adds a lap marker to the sector information.  A marker
+// with the same marker number as an existing marker replaces the
+// existing marker
 func (si *SectorInfo) AddMarker(marker *LapMarker) {
+	for i, m := range si.markers {
+		if m.Marker == marker.Marker {
+			si.markers[i] = *marker
+			return
+		}
+	}
 	si.markers = append(si.markers, *marker)
+	sort.Slice(si.markers, func(i, j int) bool { return si.markers[i].Marker < si.markers[j].Marker })
+}
+
+// Markers returns the lap markers ordered by marker number
+func (si *SectorInfo) Markers() []LapMarker {
+	return append([]LapMarker(nil), si.markers...)
+}
+
+// crossed determines if the vehicle crossed the lap marker while moving
+// from the previous epoch to the current epoch.  If the marker was crossed
+// then the fraction of the distance between the two epochs at which the
+// line was crossed is returned
+func (marker *LapMarker) crossed(prev, cur *Epoch, width float64) (fraction float64, found bool) {
+	heading := radians(float64(marker.Heading))
+	dx, dy := math.Sin(heading), math.Cos(heading)
+
+	x0, y0 := localOffset(marker.Latitude, marker.Longitude, prev.Latitude, prev.Longitude)
+	x1, y1 := localOffset(marker.Latitude, marker.Longitude, cur.Latitude, cur.Longitude)
+
+	// distance along, and across, the direction the marker is pointing
+	along0, along1 := x0*dx+y0*dy, x1*dx+y1*dy
+	if along0 >= 0 || along1 < 0 {
+		return 0, false
+	}
+
+	fraction = -along0 / (along1 - along0)
+	across := (x0*dy - y0*dx) + fraction*((x1*dy-y1*dx)-(x0*dy-y0*dx))
+	return fraction, math.Abs(across) <= width
 }
 
+// SectorAnalyzer detects when the vehicle crosses the lap markers and
+// divides the session into Laps and Sectors.  Markers must be crossed in
+// order of their marker number, starting with the start/finish line, so
+// passing close to any other marker (such as where the track doubles back
+// on itself) does not close a sector early.  The analyzer expects Epochs
+// produced by a SampleDemuxer.  All input samples are passed to the output
+// and each Sector and Lap is emitted as soon as it is completed.  Epochs
+// are updated with the lap number, lap time and lap distance they were
+// recorded in.
+// SectorTime samples are attached to the Sector closed by the marker with
+// the same number, so the logger's timing can be compared with the detected
+// timing.  A closed Sector that has not been matched holds back the output,
+// for at most one second, in case the logger's time arrives after the marker
+// was crossed
 type SectorAnalyzer struct {
 	sectorInfo *SectorInfo
+	width      float64
 }
 
-func NewSectorAnalyzer() *SectorAnalyzer { return &SectorAnalyzer{} }
+// NewSectorAnalyzer returns a SectorAnalyzer that will use the LapMarker
+// samples found in the input stream to detect laps and sectors
+func NewSectorAnalyzer() *SectorAnalyzer { return &SectorAnalyzer{width: DefaultMarkerWidth} }
 
+// SectorInfo sets the lap markers used for lap and sector detection.  When
+// sector information is supplied any LapMarker samples in the input stream
+// are ignored
 func (sa *SectorAnalyzer) SectorInfo(sectorInfo *SectorInfo) *SectorAnalyzer {
 	sa.sectorInfo = sectorInfo
 	return sa
 }
 
-// Demux will start the demux loop.  This should usually be run in
+// MarkerWidth sets the distance (in meters) to either side of a lap marker
+// that the vehicle must pass within for the marker to be considered crossed
+func (sa *SectorAnalyzer) MarkerWidth(width float64) *SectorAnalyzer {
+	sa.width = width
+	return sa
+}
+
+// sectorOutput sends the output of a SectorAnalyzer.  While a closed Sector
+// is waiting for the data logger to report its time the output is held back
+// so that the samples are still sent in order
+type sectorOutput struct {
+	ctx      context.Context
+	output   chan<- Sample
+	held     []Sample
+	waiting  *Sector
+	marker   int
+	deadline TimeOffset
+}
+
+func (so *sectorOutput) send(sample Sample) error {
+	if so.waiting != nil {
+		so.held = append(so.held, sample)
+		return nil
+	}
+	return send(so.ctx, so.output, sample)
+}
+
+// wait holds back the output until the data logger reports the time for
+// the given marker or the deadline passes
+func (so *sectorOutput) wait(sector *Sector, marker int, deadline TimeOffset) {
+	so.waiting = sector
+	so.marker = marker
+	so.deadline = deadline
+}
+
+// flush sends all of the held back output
+func (so *sectorOutput) flush() error {
+	so.waiting = nil
+	for _, sample := range so.held {
+		if err := send(so.ctx, so.output, sample); err != nil {
+			return err
+		}
+	}
+	so.held = nil
+	return nil
+}
+
+// Process will start the sector analysis loop.  This should usually be run in
 // a go routine
-func (sa *SectorAnalyzer) Process(input <-chan Sample, output chan<- Sample) {
-	for range input {
+func (sa *SectorAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	sectorInfo := sa.sectorInfo
+	if sectorInfo == nil {
+		sectorInfo = &SectorInfo{}
+	}
+
+	var prev *Epoch
+	var lap *Lap
+	var sector *Sector
+	var lapDistance Distance
+	laps := 0
+
+	// next is the index of the next marker that must be crossed.  Until
+	// the first lap starts only the start/finish line is looked for
+	next := 0
+
+	// pending are the sector times reported while the current sector is
+	// open, keyed by marker number
+	pending := make(map[int]*SectorTime)
+	out := &sectorOutput{ctx: ctx, output: output}
+
+	for sample := range input {
+		release := false
+		switch v := sample.(type) {
+		case *LapMarker:
+			if sa.sectorInfo == nil {
+				sectorInfo.AddMarker(v)
+			}
+		case *SectorTime:
+			if out.waiting != nil && v.Sector == out.marker {
+				out.waiting.LoggerTime = v
+				release = true
+			} else {
+				pending[v.Sector] = v
+			}
+		case *Epoch:
+			if out.waiting != nil && v.Stop > out.deadline {
+				if err := out.flush(); err != nil {
+					return err
+				}
+			}
+
+			if prev != nil && next < len(sectorInfo.markers) {
+				marker := sectorInfo.markers[next]
+				if fraction, found := marker.crossed(prev, v, sa.width); found {
+					offset := prev.Stop + TimeOffset(math.Round(fraction*float64(v.Stop-prev.Stop)))
+					if err := out.flush(); err != nil {
+						return err
+					}
+
+					if sector != nil {
+						sector.Stop = offset
+						sector.LoggerTime = pending[marker.Marker]
+						lap.Sectors = append(lap.Sectors, sector)
+						if sector.LoggerTime == nil {
+							out.wait(sector, marker.Marker, offset+loggerTimeWindow)
+						}
+
+						if err := out.send(sector); err != nil {
+							return err
+						}
+					}
+
+					if next == 0 {
+						if lap != nil {
+							lap.Stop = offset
+							if err := out.send(lap); err != nil {
+								return err
+							}
+						}
+						laps++
+						lap = &Lap{Number: laps, Start: offset}
+						lapDistance = prev.Distance + Distance(fraction*float64(v.Distance-prev.Distance))
+					}
+
+					sector = &Sector{Number: marker.Marker, Lap: lap.Number, Start: offset}
+					next = (next + 1) % len(sectorInfo.markers)
+					pending = make(map[int]*SectorTime)
+				}
+			}
+
+			if lap != nil {
+				v.Lap = lap.Number
+				v.LapTime = v.Stop - lap.Start
+				v.LapDistance = v.Distance - lapDistance
+				lap.Epochs = append(lap.Epochs, v)
+				sector.Epochs = append(sector.Epochs, v)
+			}
+			prev = v
+		}
+
+		if err := out.send(sample); err != nil {
+			return err
+		}
+
+		if release {
+			if err := out.flush(); err != nil {
+				return err
+			}
+		}
 	}
-	close(output)
+	return out.flush()
 }
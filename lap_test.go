@@ -0,0 +1,182 @@
+package dl
+
+import (
+	"math"
+	"testing"
+)
+
+func sectorsAndLaps(samples []Sample) (sectors []*Sector, laps []*Lap) {
+	for _, sample := range samples {
+		switch v := sample.(type) {
+		case *Sector:
+			sectors = append(sectors, v)
+		case *Lap:
+			laps = append(laps, v)
+		}
+	}
+	return sectors, laps
+}
+
+func TestSectorAnalyzer(t *testing.T) {
+	north, east, south := circleMarker(2, 0), circleMarker(5, math.Pi/2), circleMarker(7, math.Pi)
+
+	tests := []struct {
+		name    string
+		markers []*LapMarker
+		laps    int
+		numbers []int
+		times   []TimeOffset
+	}{
+		{"start/finish only", []*LapMarker{north}, 3, []int{2, 2, 2}, []TimeOffset{10000, 10000, 10000}},
+		{"three sectors", []*LapMarker{north, east, south}, 2, []int{2, 5, 7, 2, 5, 7}, []TimeOffset{2500, 2500, 5000, 2500, 2500, 5000}},
+		{"markers out of order", []*LapMarker{south, north, east}, 1, []int{2, 5, 7}, []TimeOffset{2500, 2500, 5000}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var samples []Sample
+			for _, marker := range test.markers {
+				samples = append(samples, marker)
+			}
+			samples = append(samples, circleEpochs(test.laps)...)
+
+			sectors, laps := sectorsAndLaps(runAnalyzers(t, samples, NewSectorAnalyzer()))
+			if len(laps) != test.laps {
+				t.Fatalf("Expected %d laps got %d", test.laps, len(laps))
+			}
+
+			if len(sectors) != len(test.numbers) {
+				t.Fatalf("Expected %d sectors got %d", len(test.numbers), len(sectors))
+			}
+
+			for i, sector := range sectors {
+				if sector.Number != test.numbers[i] {
+					t.Errorf("Sector %d: expected number %d got %d", i, test.numbers[i], sector.Number)
+				}
+
+				if math.Abs(float64(sector.Elapsed()-test.times[i])) > 1 {
+					t.Errorf("Sector %d: expected time %v got %v", i, test.times[i], sector.Elapsed())
+				}
+			}
+
+			for i, lap := range laps {
+				if lap.Number != i+1 {
+					t.Errorf("Expected lap number %d got %d", i+1, lap.Number)
+				}
+
+				if math.Abs(float64(lap.Elapsed()-circleLapTime)) > 1 {
+					t.Errorf("Lap %d: expected time %v got %v", lap.Number, TimeOffset(circleLapTime), lap.Elapsed())
+				}
+			}
+		})
+	}
+}
+
+func TestSectorAnalyzerSequence(t *testing.T) {
+	north, east, south := circleMarker(1, 0), circleMarker(2, math.Pi/2), circleMarker(3, math.Pi)
+	epochs := circleEpochs(1)
+
+	// a GPS glitch shortly after the start/finish line that jumps across
+	// the southern marker and back again
+	distance := epochs[9].(*Epoch).Distance
+	glitch := []Sample{
+		positionEpoch(930, 10, -circleRadius, distance),
+		positionEpoch(960, -10, -circleRadius, distance),
+	}
+
+	if _, found := south.crossed(glitch[0].(*Epoch), glitch[1].(*Epoch), DefaultMarkerWidth); !found {
+		t.Fatalf("Expected the glitch to cross the southern marker")
+	}
+
+	samples := []Sample{north, east, south}
+	samples = append(samples, epochs[:10]...)
+	samples = append(samples, glitch...)
+	samples = append(samples, epochs[10:]...)
+
+	sectors, laps := sectorsAndLaps(runAnalyzers(t, samples, NewSectorAnalyzer()))
+	if len(laps) != 1 || len(sectors) != 3 {
+		t.Fatalf("Expected 1 lap and 3 sectors got %d laps and %d sectors", len(laps), len(sectors))
+	}
+
+	for i, expected := range []TimeOffset{2500, 2500, 5000} {
+		if sectors[i].Number != i+1 {
+			t.Errorf("Sector %d: expected number %d got %d", i, i+1, sectors[i].Number)
+		}
+
+		if math.Abs(float64(sectors[i].Elapsed()-expected)) > 1 {
+			t.Errorf("Sector %d: expected time %v got %v", sectors[i].Number, expected, sectors[i].Elapsed())
+		}
+	}
+}
+
+// insertAfter inserts the sample after the Epoch that ends at stop
+func insertAfter(samples []Sample, stop TimeOffset, sample Sample) []Sample {
+	for i, s := range samples {
+		if epoch, ok := s.(*Epoch); ok && epoch.Stop == stop {
+			return append(samples[:i+1], append([]Sample{sample}, samples[i+1:]...)...)
+		}
+	}
+	panic("no epoch ends at the given offset")
+}
+
+func TestSectorAnalyzerLoggerTime(t *testing.T) {
+	// sector 1 is closed by marker 2 in the epoch ending at 5500 ms
+	tests := []struct {
+		name     string
+		after    TimeOffset
+		marker   int
+		expected bool
+	}{
+		{"before the crossing", 5400, 2, true},
+		{"after the crossing", 5500, 2, true},
+		{"shortly after the crossing", 6300, 2, true},
+		{"too long after the crossing", 6600, 2, false},
+		{"a different marker", 5400, 1, false},
+		{"while the previous sector was open", 400, 2, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sectorTime := &SectorTime{Sector: test.marker, Time: 5000}
+			samples := []Sample{circleMarker(1, 0), circleMarker(2, math.Pi)}
+			samples = insertAfter(append(samples, circleEpochs(1)...), test.after, sectorTime)
+
+			output := runAnalyzers(t, samples, NewSectorAnalyzer())
+			if len(output) != len(samples)+3 {
+				t.Fatalf("Expected %d samples got %d", len(samples)+3, len(output))
+			}
+
+			// the output is in the same order as it would have been if the
+			// output had not been held back
+			var last TimeOffset
+			for _, sample := range output {
+				switch v := sample.(type) {
+				case *Epoch:
+					if v.Stop < last {
+						t.Fatalf("Epoch %v is out of order", v.Stop)
+					}
+					last = v.Stop
+				case *Sector:
+					if v.Stop < last || v.Stop > last+100 {
+						t.Errorf("Sector %d closed at %v was emitted after the epoch ending at %v", v.Number, v.Stop, last)
+					}
+				}
+			}
+
+			sectors, _ := sectorsAndLaps(output)
+			if len(sectors) != 2 {
+				t.Fatalf("Expected 2 sectors got %d", len(sectors))
+			}
+
+			if test.expected && sectors[0].LoggerTime != sectorTime {
+				t.Errorf("Expected the logger time to be attached to sector %d", sectors[0].Number)
+			} else if !test.expected && sectors[0].LoggerTime != nil {
+				t.Errorf("Expected no logger time for sector %d got %v", sectors[0].Number, sectors[0].LoggerTime)
+			}
+
+			if sectors[1].LoggerTime != nil {
+				t.Errorf("Expected no logger time for sector %d got %v", sectors[1].Number, sectors[1].LoggerTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package dl
+
+import (
+	"context"
+	"io"
+)
+
+// DefaultBaudRate is the baud rate used by the data logger's serial link
+const DefaultBaudRate = 115200
+
+// LiveReader reads messages, in real time, from a data logger that is
+// connected by a serial link.  The data logger sends the same frames over
+// its serial link as it writes to a run file.  Like the RunReader, the
+// LiveReader never gives up on a corrupted stream, instead it waits for
+// two good frames in a row and then continues sending messages
+type LiveReader struct {
+	port   io.ReadCloser
+	reader *RunReader
+}
+
+// NewLiveReader returns a LiveReader that reads from the given port
+func NewLiveReader(port io.ReadCloser) *LiveReader {
+	return &LiveReader{
+		port:   port,
+		reader: NewRunReader(port),
+	}
+}
+
+// OpenLiveReader opens the serial device (e.g. /dev/ttyUSB0) and configures
+// it for raw 8N1 communication at the given baud rate
+func OpenLiveReader(device string, baud int) (*LiveReader, error) {
+	port, err := openSerial(device, baud)
+	if err != nil {
+		return nil, err
+	}
+	return NewLiveReader(port), nil
+}
+
+// Read reads messages from the port until the port is closed or the
+// context is cancelled.  Checksum errors and unknown channels cause the
+// reader to re-synchronize with the stream rather than stop and are
+// reported with Corruption samples.  The port is closed when the context
+// is cancelled
+func (lr *LiveReader) Read(ctx context.Context) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// unblock any read that is waiting on the port
+			lr.port.Close()
+		case <-done:
+		}
+	}()
+
+	lr.reader.ctx = ctx
+	err := lr.reader.readFrames()
+	close(lr.reader.messages)
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Output returns the channel that Samples can be read from
+func (lr *LiveReader) Output() <-chan Sample {
+	return lr.reader.Output()
+}
+
+// ChecksumErrors returns the number of frames that were discarded because
+// their checksum was invalid
+func (lr *LiveReader) ChecksumErrors() int {
+	return lr.reader.ChecksumErrors()
+}
+
+// Close closes the underlying port
+func (lr *LiveReader) Close() error {
+	return lr.port.Close()
+}
@@ -0,0 +1,164 @@
+package dl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a pseudo-terminal pair and returns the master side along
+// with the name of the slave device
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+		t.Fatalf("Failed to unlock the pseudo-terminal: %v", err)
+	}
+
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+		t.Fatalf("Failed to get the pseudo-terminal number: %v", err)
+	}
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+// startLiveReader opens the slave side of the pseudo-terminal and starts
+// reading from it.  The result of Read is sent on the returned channel
+func startLiveReader(t *testing.T, ctx context.Context, device string) (*LiveReader, <-chan error) {
+	t.Helper()
+	reader, err := OpenLiveReader(device, DefaultBaudRate)
+	if err != nil {
+		t.Fatalf("OpenLiveReader() returned %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- reader.Read(ctx) }()
+	return reader, done
+}
+
+func waitRead(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Read() did not return")
+	}
+	return nil
+}
+
+func TestLiveReaderFrames(t *testing.T) {
+	master, device := openPTY(t)
+	defer master.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader, done := startLiveReader(t, ctx, device)
+
+	buf := &bytes.Buffer{}
+	writer := NewRunWriter(buf)
+	for i := 1; i <= 10; i++ {
+		writer.Write(&Timestamp{Timestamp: TimeOffset(i * 10)})
+	}
+	writer.Flush()
+
+	// corrupt the checksum of the fifth frame
+	frames := buf.Bytes()
+	frames[4*5+4]++
+	if _, err := master.Write(frames); err != nil {
+		t.Fatalf("Failed to write to the pseudo-terminal: %v", err)
+	}
+
+	var timestamps []TimeOffset
+	var corrupted []*Corruption
+	for len(timestamps) < 9 {
+		select {
+		case sample := <-reader.Output():
+			switch v := sample.(type) {
+			case *Message:
+				timestamp := &Timestamp{}
+				timestamp.UnmarshalBinary(v.Data)
+				timestamps = append(timestamps, timestamp.Timestamp)
+			case *Corruption:
+				corrupted = append(corrupted, v)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected 9 messages got %d", len(timestamps))
+		}
+	}
+
+	expected := []TimeOffset{10, 20, 30, 40, 60, 70, 80, 90, 100}
+	for i, timestamp := range timestamps {
+		if timestamp != expected[i] {
+			t.Errorf("Message %d: expected timestamp %v got %v", i, expected[i], timestamp)
+		}
+	}
+
+	if len(corrupted) != 1 || corrupted[0].Offset != 20 || corrupted[0].Length != 5 {
+		t.Errorf("Expected 5 corrupted bytes at offset 20 got %v", corrupted)
+	}
+
+	if reader.ChecksumErrors() != 1 {
+		t.Errorf("Expected 1 checksum error got %d", reader.ChecksumErrors())
+	}
+
+	cancel()
+	if err := waitRead(t, done); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled got %v", err)
+	}
+}
+
+func TestLiveReaderCancel(t *testing.T) {
+	master, device := openPTY(t)
+	defer master.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, done := startLiveReader(t, ctx, device)
+
+	// Read is blocked waiting for the port until the context is cancelled
+	cancel()
+	if err := waitRead(t, done); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled got %v", err)
+	}
+
+	if _, open := <-reader.Output(); open {
+		t.Errorf("Expected the output to be closed")
+	}
+
+	if err := reader.Close(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Expected the port to be closed already, Close() returned %v", err)
+	}
+}
+
+func TestLiveReaderError(t *testing.T) {
+	master, device := openPTY(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader, done := startLiveReader(t, ctx, device)
+	defer reader.Close()
+
+	// hanging up the master side causes reads of the slave side to fail
+	master.Close()
+	err := waitRead(t, done)
+	if err == nil || errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a read error got %v", err)
+	}
+
+	if _, open := <-reader.Output(); open {
+		t.Errorf("Expected the output to be closed")
+	}
+}
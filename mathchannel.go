@@ -0,0 +1,540 @@
+package dl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mathFunc evaluates a compiled expression for an Epoch
+type mathFunc func(epoch *Epoch) float64
+
+// mathScope is the set of Measurement names that an expression may refer to
+type mathScope map[string]bool
+
+// epochFields maps the names of the numeric Epoch fields to their index
+var epochFields = make(map[string]int)
+
+func init() {
+	epochType := reflect.TypeOf(Epoch{})
+	for i := 0; i < epochType.NumField(); i++ {
+		switch epochType.Field(i).Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			epochFields[epochType.Field(i).Name] = i
+		}
+	}
+}
+
+// mathAliases are the short names of Epoch fields.  A Measurement with
+// the same name takes precedence over the alias
+var mathAliases = map[string]string{"rpm": "EngineSpeed"}
+
+// inputPattern matches the names of the analog and frequency input channels
+var inputPattern = regexp.MustCompile(`^(Analog|Frequency|ExtendedFrequency)Channel([0-9]+)$`)
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func compileField(index int) mathFunc {
+	return func(epoch *Epoch) float64 {
+		field := reflect.ValueOf(epoch).Elem().Field(index)
+		switch field.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return field.Float()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return float64(field.Uint())
+		}
+		return float64(field.Int())
+	}
+}
+
+func compileMeasurement(name string) mathFunc {
+	return func(epoch *Epoch) float64 {
+		if measurement, found := epoch.Measurements[name]; found {
+			return measurement.Value
+		}
+		return math.NaN()
+	}
+}
+
+func (scope mathScope) compileIdent(name string) (mathFunc, error) {
+	switch name {
+	case "true":
+		return func(*Epoch) float64 { return 1 }, nil
+	case "false":
+		return func(*Epoch) float64 { return 0 }, nil
+	}
+
+	if match := inputPattern.FindStringSubmatch(name); match != nil {
+		n, _ := strconv.Atoi(match[2])
+		first, last := FrequencyChannel1, FrequencyChannel5
+		switch match[1] {
+		case "Analog":
+			first, last = AnalogChannel1, AnalogChannel32
+		case "ExtendedFrequency":
+			first, last = ExtendedFrequencyChannel1, ExtendedFrequencyChannel4
+		}
+
+		channel := first + Channel(n-1)
+		if n < 1 || channel > last {
+			return nil, newParseError(fmt.Sprintf("Unknown input channel %s", name))
+		}
+
+		if match[1] == "Analog" {
+			return func(epoch *Epoch) float64 {
+				if voltage, found := epoch.AnalogInputs[channel]; found {
+					return float64(voltage)
+				}
+				return math.NaN()
+			}, nil
+		}
+
+		return func(epoch *Epoch) float64 {
+			if frequency, found := epoch.FrequencyInputs[channel]; found {
+				return float64(frequency)
+			}
+			return math.NaN()
+		}, nil
+	}
+
+	if index, found := epochFields[name]; found {
+		return compileField(index), nil
+	}
+
+	if scope[name] {
+		return compileMeasurement(name), nil
+	}
+
+	if field, found := mathAliases[name]; found {
+		return compileField(epochFields[field]), nil
+	}
+	return nil, newParseError(fmt.Sprintf("Unknown identifier %q", name))
+}
+
+func (scope mathScope) compileUnary(expr *ast.UnaryExpr) (mathFunc, error) {
+	x, err := scope.compileExpr(expr.X)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.Op {
+	case token.ADD:
+		return x, nil
+	case token.SUB:
+		return func(epoch *Epoch) float64 { return -x(epoch) }, nil
+	case token.NOT:
+		return func(epoch *Epoch) float64 { return boolValue(x(epoch) == 0) }, nil
+	}
+	return nil, newParseError(fmt.Sprintf("Unsupported operator %v", expr.Op))
+}
+
+func (scope mathScope) compileBinary(expr *ast.BinaryExpr) (mathFunc, error) {
+	x, err := scope.compileExpr(expr.X)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := scope.compileExpr(expr.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	var op func(a, b float64) float64
+	switch expr.Op {
+	case token.ADD:
+		op = func(a, b float64) float64 { return a + b }
+	case token.SUB:
+		op = func(a, b float64) float64 { return a - b }
+	case token.MUL:
+		op = func(a, b float64) float64 { return a * b }
+	case token.QUO:
+		op = func(a, b float64) float64 { return a / b }
+	case token.REM:
+		op = math.Mod
+	case token.LSS:
+		op = func(a, b float64) float64 { return boolValue(a < b) }
+	case token.LEQ:
+		op = func(a, b float64) float64 { return boolValue(a <= b) }
+	case token.GTR:
+		op = func(a, b float64) float64 { return boolValue(a > b) }
+	case token.GEQ:
+		op = func(a, b float64) float64 { return boolValue(a >= b) }
+	case token.EQL:
+		op = func(a, b float64) float64 { return boolValue(a == b) }
+	case token.NEQ:
+		op = func(a, b float64) float64 { return boolValue(a != b) }
+	case token.LAND:
+		op = func(a, b float64) float64 { return boolValue(a != 0 && b != 0) }
+	case token.LOR:
+		op = func(a, b float64) float64 { return boolValue(a != 0 || b != 0) }
+	default:
+		return nil, newParseError(fmt.Sprintf("Unsupported operator %v", expr.Op))
+	}
+
+	// both operands are always evaluated so that stateful functions,
+	// such as derivative, see every epoch
+	return func(epoch *Epoch) float64 { return op(x(epoch), y(epoch)) }, nil
+}
+
+// derivative returns the rate of change, per second, of x
+func derivative(x mathFunc) mathFunc {
+	rate := math.NaN()
+	var prevValue float64
+	var prevTime TimeOffset
+	started := false
+	return func(epoch *Epoch) float64 {
+		value := x(epoch)
+		if started && epoch.Stop > prevTime {
+			rate = (value - prevValue) / (float64(epoch.Stop-prevTime) / 1000)
+		}
+		prevValue, prevTime, started = value, epoch.Stop, true
+		return rate
+	}
+}
+
+// integrate returns the integral of x over time (in seconds) since the
+// beginning of the run.  Epochs where x has no value are skipped
+func integrate(x mathFunc) mathFunc {
+	sum := 0.0
+	var prevValue float64
+	var prevTime TimeOffset
+	started := false
+	return func(epoch *Epoch) float64 {
+		value := x(epoch)
+		if math.IsNaN(value) {
+			return sum
+		}
+
+		if started && epoch.Stop > prevTime {
+			sum += (value + prevValue) / 2 * float64(epoch.Stop-prevTime) / 1000
+		}
+		prevValue, prevTime, started = value, epoch.Stop, true
+		return sum
+	}
+}
+
+// smooth returns the moving average of the last n values of x
+func smooth(x mathFunc, n int) mathFunc {
+	window := make([]float64, 0, n)
+	next := 0
+	return func(epoch *Epoch) float64 {
+		if value := x(epoch); !math.IsNaN(value) {
+			if len(window) < n {
+				window = append(window, value)
+			} else {
+				window[next] = value
+				next = (next + 1) % n
+			}
+		}
+
+		if len(window) == 0 {
+			return math.NaN()
+		}
+
+		sum := 0.0
+		for _, value := range window {
+			sum += value
+		}
+		return sum / float64(len(window))
+	}
+}
+
+// mathArity is the number of arguments expected by the fixed argument
+// functions
+var mathArity = map[string]int{"abs": 1, "sqrt": 1, "derivative": 1, "integrate": 1, "ifelse": 3}
+
+func (scope mathScope) compileCall(expr *ast.CallExpr) (mathFunc, error) {
+	ident, ok := expr.Fun.(*ast.Ident)
+	if !ok {
+		return nil, newParseError("Unsupported function call")
+	}
+	name := ident.Name
+
+	// functions that take a literal argument
+	switch name {
+	case "measurement":
+		if len(expr.Args) == 1 {
+			if lit, ok := expr.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				measurement, _ := strconv.Unquote(lit.Value)
+				if !scope[measurement] {
+					return nil, newParseError(fmt.Sprintf("Unknown measurement %q", measurement))
+				}
+				return compileMeasurement(measurement), nil
+			}
+		}
+		return nil, newParseError("measurement expects a quoted measurement name")
+	case "smooth":
+		if len(expr.Args) == 2 {
+			if lit, ok := expr.Args[1].(*ast.BasicLit); ok && lit.Kind == token.INT {
+				if n, err := strconv.Atoi(lit.Value); err == nil && n > 0 {
+					x, err := scope.compileExpr(expr.Args[0])
+					if err != nil {
+						return nil, err
+					}
+					return smooth(x, n), nil
+				}
+			}
+		}
+		return nil, newParseError("smooth expects an expression and a positive number of samples")
+	}
+
+	args := make([]mathFunc, len(expr.Args))
+	for i, arg := range expr.Args {
+		var err error
+		if args[i], err = scope.compileExpr(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	if n, found := mathArity[name]; found && len(args) != n {
+		return nil, newParseError(fmt.Sprintf("%s expects %d argument(s)", name, n))
+	}
+
+	switch name {
+	case "abs":
+		return func(epoch *Epoch) float64 { return math.Abs(args[0](epoch)) }, nil
+	case "sqrt":
+		return func(epoch *Epoch) float64 { return math.Sqrt(args[0](epoch)) }, nil
+	case "derivative":
+		return derivative(args[0]), nil
+	case "integrate":
+		return integrate(args[0]), nil
+	case "ifelse":
+		return func(epoch *Epoch) float64 {
+			condition, a, b := args[0](epoch), args[1](epoch), args[2](epoch)
+			if condition != 0 && !math.IsNaN(condition) {
+				return a
+			}
+			return b
+		}, nil
+	case "min", "max":
+		if len(args) == 0 {
+			return nil, newParseError(fmt.Sprintf("%s expects at least one argument", name))
+		}
+
+		choose := math.Min
+		if name == "max" {
+			choose = math.Max
+		}
+
+		return func(epoch *Epoch) float64 {
+			value := args[0](epoch)
+			for _, arg := range args[1:] {
+				value = choose(value, arg(epoch))
+			}
+			return value
+		}, nil
+	}
+	return nil, newParseError(fmt.Sprintf("Unknown function %q", name))
+}
+
+func (scope mathScope) compileExpr(expr ast.Expr) (mathFunc, error) {
+	switch v := expr.(type) {
+	case *ast.BasicLit:
+		if v.Kind == token.INT || v.Kind == token.FLOAT {
+			value, err := strconv.ParseFloat(v.Value, 64)
+			if err != nil {
+				return nil, newParseError(fmt.Sprintf("Invalid number %s", v.Value))
+			}
+			return func(*Epoch) float64 { return value }, nil
+		}
+		return nil, newParseError(fmt.Sprintf("Unexpected literal %s", v.Value))
+	case *ast.Ident:
+		return scope.compileIdent(v.Name)
+	case *ast.ParenExpr:
+		return scope.compileExpr(v.X)
+	case *ast.UnaryExpr:
+		return scope.compileUnary(v)
+	case *ast.BinaryExpr:
+		return scope.compileBinary(v)
+	case *ast.CallExpr:
+		return scope.compileCall(v)
+	}
+	return nil, newParseError(fmt.Sprintf("Unsupported expression %T", expr))
+}
+
+// MathChannel is a Measurement calculated from an expression over the
+// values of each Epoch.  Expressions use Go syntax for arithmetic (+, -, *,
+// /, %), comparison (<, <=, >, >=, ==, !=) and logical (&&, ||, !)
+// operators.  Comparison and logical operators produce 1 for true and 0
+// for false.
+//
+// Identifiers refer to the numeric Epoch fields (such as Speed, EngineSpeed
+// or LateralAcceleration), to the analog and frequency inputs
+// (AnalogChannel1 to AnalogChannel32, FrequencyChannel1 to
+// FrequencyChannel5 and ExtendedFrequencyChannel1 to
+// ExtendedFrequencyChannel4) or to the names of the Measurements given when
+// the channel is compiled.  Names are case sensitive.  The engine speed can
+// also be referred to as rpm, unless a Measurement is named rpm.
+// Measurements with names that are not valid identifiers can be referred to
+// with measurement("Name").
+//
+// The functions abs(x), sqrt(x), min(x, ...), max(x, ...) and
+// ifelse(condition, a, b) are available along with derivative(x), which
+// returns the rate of change of x per second, integrate(x), which returns
+// the integral of x over time in seconds and smooth(x, n), which returns
+// the average of the last n values of x.  These functions keep state
+// between calls to Evaluate.  The MathAnalyzer starts each run with fresh
+// state, so the same MathChannel can be used to process several runs
+type MathChannel struct {
+	// Name is the name of the calculated measurement
+	Name string
+
+	// Unit is the unit of the calculated measurement
+	Unit string
+
+	expr       ast.Expr
+	scope      mathScope
+	expression mathFunc
+}
+
+// NewMathChannel compiles the expression into a MathChannel.  Measurements
+// are the names of the Measurements, such as calibrated sensors or other
+// math channels, that the expression may refer to.  A ParseError is
+// returned if the expression is not valid or refers to an unknown name
+func NewMathChannel(name, unit, expression string, measurements ...string) (*MathChannel, error) {
+	expr, err := parser.ParseExpr(expression)
+	if err != nil {
+		return nil, wrapParseError(err, fmt.Sprintf("%s: %v", name, err))
+	}
+
+	mc := &MathChannel{Name: name, Unit: unit, expr: expr, scope: make(mathScope)}
+	for _, measurement := range measurements {
+		mc.scope[measurement] = true
+	}
+
+	if mc.expression, err = mc.compile(); err != nil {
+		return nil, wrapParseError(err, fmt.Sprintf("%s: %v", name, err))
+	}
+	return mc, nil
+}
+
+// compile returns a new evaluator for the expression, so that stateful
+// functions start again from the beginning
+func (mc *MathChannel) compile() (mathFunc, error) { return mc.scope.compileExpr(mc.expr) }
+
+// ParseMathChannel compiles a math channel definition of the form
+// "name = expression" or "name [unit] = expression".  Measurements are the
+// names of the Measurements the expression may refer to
+func ParseMathChannel(definition string, measurements ...string) (*MathChannel, error) {
+	i := strings.Index(definition, "=")
+	if i < 0 || strings.HasPrefix(definition[i:], "==") {
+		return nil, newParseError(fmt.Sprintf("Expected name = expression in %q", definition))
+	}
+
+	name, unit := strings.TrimSpace(definition[:i]), ""
+	if j := strings.Index(name, "["); j >= 0 && strings.HasSuffix(name, "]") {
+		name, unit = strings.TrimSpace(name[:j]), strings.TrimSpace(name[j+1:len(name)-1])
+	}
+
+	if name == "" {
+		return nil, newParseError(fmt.Sprintf("Missing name in %q", definition))
+	}
+	return NewMathChannel(name, unit, definition[i+1:], measurements...)
+}
+
+// ReadMathChannels reads math channel definitions from the reader, one per
+// line.  Blank lines and lines beginning with # are ignored.  Expressions
+// may refer to the sensors of the calibration, which may be nil, and to the
+// channels defined on earlier lines
+func ReadMathChannels(reader io.Reader, calibration *Calibration) (channels []*MathChannel, err error) {
+	var measurements []string
+	if calibration != nil {
+		for _, sensor := range calibration.Sensors {
+			measurements = append(measurements, sensor.Name)
+		}
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		channel, err := ParseMathChannel(line, measurements...)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+		measurements = append(measurements, channel.Name)
+	}
+	return channels, scanner.Err()
+}
+
+// LoadMathChannels reads the math channel definitions from the named file.
+// Expressions may refer to the sensors of the calibration, which may be nil
+func LoadMathChannels(filename string, calibration *Calibration) ([]*MathChannel, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadMathChannels(file, calibration)
+}
+
+// Evaluate calculates the value of the math channel for the Epoch.  NaN is
+// returned if any of the values the expression refers to are missing
+func (mc *MathChannel) Evaluate(epoch *Epoch) float64 { return mc.expression(epoch) }
+
+// MathAnalyzer evaluates math channels for each Epoch and adds the results
+// to the Epoch Measurements.  Channels are evaluated in order, so later
+// channels may refer to the results of earlier ones.  Results that are not
+// finite, such as when an input is missing, are not added.  All input
+// samples are passed to the output
+type MathAnalyzer struct {
+	channels []*MathChannel
+}
+
+// NewMathAnalyzer returns a MathAnalyzer for the given math channels
+func NewMathAnalyzer(channels ...*MathChannel) *MathAnalyzer {
+	return &MathAnalyzer{channels: channels}
+}
+
+// Process will start the math channel loop.  This should usually be run in
+// a go routine
+func (ma *MathAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	expressions := make([]mathFunc, len(ma.channels))
+	for i, channel := range ma.channels {
+		var err error
+		if expressions[i], err = channel.compile(); err != nil {
+			return err
+		}
+	}
+
+	for sample := range input {
+		if epoch, ok := sample.(*Epoch); ok {
+			if epoch.Measurements == nil {
+				epoch.Measurements = make(map[string]Measurement)
+			}
+
+			for i, channel := range ma.channels {
+				value := expressions[i](epoch)
+				if !math.IsNaN(value) && !math.IsInf(value, 0) {
+					epoch.Measurements[channel.Name] = Measurement{Value: value, Unit: channel.Unit}
+				}
+			}
+		}
+
+		if err := send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
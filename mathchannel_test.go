@@ -0,0 +1,147 @@
+package dl
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestMathChannel(t *testing.T) {
+	epoch := &Epoch{
+		Speed:        20,
+		EngineSpeed:  6000,
+		AnalogInputs: map[Channel]Voltage{AnalogChannel3: 300, AnalogChannel4: 100},
+		Measurements: map[string]Measurement{"rpm": {Value: 5000}, "Oil Temperature": {Value: 90}},
+	}
+
+	tests := []struct {
+		expression   string
+		measurements []string
+		expected     float64
+		parseErr     bool
+	}{
+		{"AnalogChannel3 / (AnalogChannel3 + AnalogChannel4)", nil, 0.75, false},
+		{"EngineSpeed / Speed", nil, 300, false},
+		{"rpm / Speed", []string{"rpm"}, 250, false},
+		{"rpm / Speed", nil, 300, false},
+		{"FrequencyChannel1 * 60", nil, math.NaN(), false},
+		{"Rpm / Speed", []string{"rpm"}, 0, true},
+		{`measurement("Oil Temperature") - 10`, []string{"Oil Temperature"}, 80, false},
+		{`measurement("Oil Temperature")`, nil, 0, true},
+		{"AnalogChannel5", nil, math.NaN(), false},
+		{"AnalogChannel33", nil, 0, true},
+		{"ifelse(Speed > 10, max(1, 2, 3), min(1, 2))", nil, 3, false},
+		{"abs(-Speed) + sqrt(16)", nil, 24, false},
+		{"!true || false", nil, 0, false},
+		{"unknown(Speed)", nil, 0, true},
+		{"Speed +", nil, 0, true},
+	}
+
+	for _, test := range tests {
+		mc, err := NewMathChannel("test", "", test.expression, test.measurements...)
+		var parseErr *ParseError
+		if test.parseErr {
+			if !errors.As(err, &parseErr) {
+				t.Errorf("%q: expected a ParseError got %v", test.expression, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: NewMathChannel() returned %v", test.expression, err)
+			continue
+		}
+
+		value := mc.Evaluate(epoch)
+		if math.IsNaN(test.expected) != math.IsNaN(value) || (!math.IsNaN(value) && math.Abs(value-test.expected) > 1e-9) {
+			t.Errorf("%q: expected %v got %v", test.expression, test.expected, value)
+		}
+	}
+}
+
+func TestReadMathChannels(t *testing.T) {
+	calibration := &Calibration{Sensors: []*Sensor{{Channel: AnalogChannel1, Name: "rpm", Conversion: &Linear{Scale: 2}}}}
+	tests := []struct {
+		input       string
+		calibration *Calibration
+		names       []string
+		parseErr    bool
+	}{
+		{"ratio = rpm / Speed\n# comment\n\ndouble [x] = 2 * ratio\n", calibration, []string{"ratio", "double"}, false},
+		{"ratio = oil / Speed\n", nil, nil, true},
+		{"double = 2 * ratio\nratio = rpm / Speed\n", calibration, nil, true},
+		{"missing expression\n", nil, nil, true},
+	}
+
+	for _, test := range tests {
+		channels, err := ReadMathChannels(strings.NewReader(test.input), test.calibration)
+		var parseErr *ParseError
+		if test.parseErr {
+			if !errors.As(err, &parseErr) {
+				t.Errorf("%q: expected a ParseError got %v", test.input, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: ReadMathChannels() returned %v", test.input, err)
+			continue
+		}
+
+		var names []string
+		for _, channel := range channels {
+			names = append(names, channel.Name)
+		}
+
+		if strings.Join(names, ",") != strings.Join(test.names, ",") {
+			t.Errorf("%q: expected channels %v got %v", test.input, test.names, names)
+		}
+	}
+}
+
+func TestMathAnalyzer(t *testing.T) {
+	calibration := &Calibration{Sensors: []*Sensor{{Channel: AnalogChannel1, Name: "rpm", Conversion: &Linear{Scale: 2}}}}
+	channels, err := ReadMathChannels(strings.NewReader("ratio = rpm / Speed\ndistance = integrate(Speed)\nrate = derivative(Speed)\n"), calibration)
+	if err != nil {
+		t.Fatalf("ReadMathChannels() returned %v", err)
+	}
+
+	samples := func() []Sample {
+		return []Sample{
+			&Epoch{Stop: 0, Speed: 10, AnalogInputs: map[Channel]Voltage{AnalogChannel1: 1000}},
+			&Epoch{Stop: 1000, Speed: 20, AnalogInputs: map[Channel]Voltage{AnalogChannel1: 2000}},
+			&Epoch{Stop: 2000, Speed: 20},
+		}
+	}
+
+	expected := []map[string]float64{
+		{"ratio": 200, "distance": 0},
+		{"ratio": 200, "distance": 15, "rate": 10},
+		{"distance": 35, "rate": 0},
+	}
+
+	analyzer := NewMathAnalyzer(channels...)
+
+	// the same analyzer processes two runs to check that integrate and
+	// derivative start again for each run
+	for run := 0; run < 2; run++ {
+		output := runAnalyzers(t, samples(), NewCalibrationAnalyzer(calibration), analyzer)
+		for i, sample := range output {
+			epoch := sample.(*Epoch)
+			for name, value := range expected[i] {
+				if measurement, found := epoch.Measurements[name]; !found || math.Abs(measurement.Value-value) > 1e-9 {
+					t.Errorf("run %d epoch %d: expected %s = %v got %v", run, i, name, value, epoch.Measurements[name])
+				}
+			}
+
+			for _, name := range []string{"ratio", "rate"} {
+				if _, found := expected[i][name]; !found {
+					if measurement, found := epoch.Measurements[name]; found {
+						t.Errorf("run %d epoch %d: expected no %s got %v", run, i, name, measurement)
+					}
+				}
+			}
+		}
+	}
+}
@@ -9,6 +9,9 @@ import (
 // message includes the channel the data was received for, the data
 // received and the checksum byte sent by the data logger
 type Message struct {
+	// Offset is the byte offset of the beginning of the message in the input
+	Offset int64
+
 	// Channel is the data channel the data was received for
 	Channel Channel
 
@@ -31,16 +34,54 @@ func (message *Message) Length() int {
 	return len(message.Data) + 2
 }
 
+// newMessage creates a message for the given channel and data and
+// computes the checksum
+func newMessage(channel Channel, data []byte) *Message {
+	message := &Message{Channel: channel, Data: data}
+	message.Checksum = message.computeChecksum()
+	return message
+}
+
+func (message *Message) computeChecksum() byte {
+	checksum := byte(message.Channel)
+	for i := 0; i < len(message.Data); i++ {
+		checksum += message.Data[i]
+	}
+	return checksum
+}
+
 // Valid computes the checksum of the message's data and compares it
 // to the expected checksum.  If the message's data is valid then
 // Valid() returns true
 func (message *Message) Valid() bool {
-	checksum := byte(message.Channel)
-	for i := 0; i < len(message.Data); i++ {
-		checksum += message.Data[i]
+	return message.computeChecksum() == message.Checksum
+}
+
+// MarshalBinary encodes the message into a complete data frame: the
+// channel byte, the data and the checksum byte. The message's checksum
+// is written as-is so that invalid messages are reproduced faithfully
+func (message *Message) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, message.Length())
+	buf = append(buf, byte(message.Channel))
+	buf = append(buf, message.Data...)
+	buf = append(buf, message.Checksum)
+	return buf, nil
+}
+
+// Decode unmarshals the message's data into a sample for the message's
+// channel.  If the channel has no decoder then nil is returned.  Decoding
+// failures are returned as a *FrameError
+func (message *Message) Decode() (ParseableSample, error) {
+	sample := sampleFactory(message.Channel, message.Data)
+	if sample == nil {
+		return nil, nil
 	}
 
-	return checksum == message.Checksum
+	if err := sample.UnmarshalBinary(message.Data); err != nil {
+		frame, _ := message.MarshalBinary()
+		return sample, newFrameError(message.Offset, message.Channel, frame, err)
+	}
+	return sample, nil
 }
 
 func hexDump(buf []byte) string {
@@ -1,30 +1,56 @@
 package dl
 
 import (
-	"fmt"
+	"context"
 )
 
+// DecodeError is emitted by the RunParser, in place of a sample, when a
+// message cannot be decoded
+type DecodeError struct {
+	// Message is the message that could not be decoded
+	Message *Message
+
+	// Err is the decoding error, usually a *FrameError
+	Err error
+}
+
+// Type returns the Sample Type, in this case "DecodeError"
+func (*DecodeError) Type() string { return "DecodeError" }
+
+// Error returns the underlying decoding error
+func (de *DecodeError) Error() string { return de.Err.Error() }
+
+// Unwrap returns the underlying decoding error
+func (de *DecodeError) Unwrap() error { return de.Err }
+
 // RunParser is used to read data messages from the data loger
 // and parse them into data samples to be used by a demuxer or
 // other downstream processor
 type RunParser struct {
 }
 
-// Process accepts a sample message and unmarshals the data specific data types
-func (rp *RunParser) Process(input <-chan Sample, output chan<- Sample) {
+// Process accepts a sample message and unmarshals the data specific data types.
+// Messages for channels that have no decoder are passed to the output
+// unchanged, as are samples other than messages.  Messages that fail to
+// decode are replaced by a DecodeError
+func (rp *RunParser) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) (err error) {
 	for sample := range input {
 		if message, ok := sample.(*Message); ok {
-			newSample := sampleFactory(message.Channel, message.Data)
-			if newSample == nil {
-				if message.Channel != RunInformationChannel {
-					fmt.Printf("Message: %v\n", message)
-				}
-				output <- sample
+			newSample, decodeErr := message.Decode()
+			if decodeErr != nil {
+				err = send(ctx, output, &DecodeError{Message: message, Err: decodeErr})
+			} else if newSample == nil {
+				err = send(ctx, output, sample)
 			} else {
-				newSample.UnmarshalBinary(message.Data)
-				output <- newSample
+				err = send(ctx, output, newSample)
 			}
+		} else {
+			err = send(ctx, output, sample)
+		}
+
+		if err != nil {
+			break
 		}
 	}
-	close(output)
+	return err
 }
@@ -2,14 +2,46 @@ package dl
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 )
 
+// Corruption is emitted by the RunReader when a region of the input
+// could not be decoded into messages.  The reader skips over the region
+// and resynchronizes with the stream once it finds two good frames in a row
+type Corruption struct {
+	// Offset is the byte offset of the beginning of the region
+	Offset int64
+
+	// Length is the number of bytes that were skipped
+	Length int64
+
+	// Reason is the error that caused the frame at Offset, the first
+	// frame in the region, to be rejected. Reason is usually a *FrameError
+	Reason error
+
+	// zeros indicates that every byte in the region is zero
+	zeros bool
+}
+
+// Type returns the Sample Type, in this case "Corruption"
+func (*Corruption) Type() string { return "Corruption" }
+
+// String returns a string representation of the corrupted region
+func (corruption *Corruption) String() string {
+	return fmt.Sprintf("%d bytes skipped at offset %d: %v", corruption.Length, corruption.Offset, corruption.Reason)
+}
+
 // RunReader reads a run file and outputs the data channel
 // messages
 type RunReader struct {
-	reader   *bufio.Reader
-	messages chan Sample
+	ctx            context.Context
+	reader         *bufio.Reader
+	messages       chan Sample
+	offset         int64
+	checksumErrors int
 }
 
 // NewRunReader takes an io.Reader object and returns a
@@ -22,71 +54,150 @@ func NewRunReader(reader io.Reader) *RunReader {
 	}
 }
 
-func (rr *RunReader) emit(message *Message) {
-	rr.messages <- message
+func (rr *RunReader) emit(sample Sample) error {
+	return send(rr.ctx, rr.messages, sample)
 }
 
-func (rr *RunReader) readChannel() error {
-	b, err := rr.reader.ReadByte()
-	if err == nil {
-		channel := Channel(b)
-		length := channelLengths[channel] - 1
+// peekFrame decodes the frame that begins at the given distance from the
+// current position, without consuming any input.  Frames that cannot be
+// decoded are reported with a *FrameError.  io.EOF is returned only if the
+// input ends exactly where the frame would begin
+func (rr *RunReader) peekFrame(at int) (*Message, error) {
+	offset := rr.offset + int64(at)
+	buf, err := rr.reader.Peek(at + 1)
+	if err != nil {
+		return nil, err
+	}
 
-		if length < 0 {
-			return ErrUnknownLength
-		}
+	channel := Channel(buf[at])
+	length, _ := ChannelLength(channel)
+	if length < 2 {
+		return nil, newFrameError(offset, channel, []byte{buf[at]}, ErrUnknownLength)
+	}
 
-		buf := make([]byte, length)
-		_, err = io.ReadFull(rr.reader, buf)
-		if err == nil {
-			msg := &Message{Channel: channel, Data: buf[0 : length-1], Checksum: buf[length-1]}
-			if msg.Valid() {
-				rr.emit(msg)
-			} else {
-				msg = nil
-				err = ErrChecksum
-			}
-		}
+	buf, err = rr.reader.Peek(at + length)
+	if err == io.EOF {
+		return nil, newFrameError(offset, channel, append([]byte(nil), buf[at:]...), io.ErrUnexpectedEOF)
+	} else if err != nil {
+		return nil, err
 	}
-	return err
+
+	data := make([]byte, length-2)
+	copy(data, buf[at+1:])
+	msg := &Message{Offset: offset, Channel: channel, Data: data, Checksum: buf[at+length-1]}
+	if !msg.Valid() {
+		return nil, newFrameError(offset, channel, append([]byte(nil), buf[at:at+length]...), ErrChecksum)
+	}
+	return msg, nil
 }
 
-func (rr *RunReader) synchronize() error {
-	found := 0
-	err := rr.readChannel()
-	// look for two frames in a row that have a correct checksum
-	for err == ErrUnknownLength || err == ErrChecksum || (err == nil && found < 2) {
-		if err == nil {
-			found++
-		} else if err == ErrUnknownLength || err == ErrChecksum {
-			found = 0
-		}
-		err = rr.readChannel()
+// skip discards a single byte of input and adds it to the corrupted region
+func (rr *RunReader) skip(corruption *Corruption) error {
+	b, err := rr.reader.ReadByte()
+	if err == nil {
+		rr.offset++
+		corruption.Length++
+		corruption.zeros = corruption.zeros && b == 0x00
 	}
 	return err
 }
 
-func (rr *RunReader) Read() error {
-	err := rr.synchronize()
+// readFrames reads frames until the end of the input.  Until synchronized,
+// a frame is only accepted if the frame following it is also good.  When a
+// bad frame is encountered the input is skipped one byte at a time until
+// the reader is synchronized again and the skipped region is emitted as a
+// Corruption sample
+func (rr *RunReader) readFrames() (err error) {
+	var corruption *Corruption
+	synchronized := false
+
+	// boundary is the offset where the next frame is expected to begin.
+	// Frames are followed through a corrupted region for as long as their
+	// lengths are known, so that each frame with a bad checksum is counted
+	boundary := rr.offset
 
 	for err == nil {
-		err = rr.readChannel()
-	}
+		var msg *Message
+		msg, err = rr.peekFrame(0)
+		if err == io.EOF {
+			break
+		}
+
+		if err == nil && !synchronized {
+			// look for two frames in a row that have a correct checksum,
+			// a good frame at the very end of the input is also accepted
+			if _, err = rr.peekFrame(msg.Length()); err == io.EOF {
+				err = nil
+			} else if errors.As(err, new(*FrameError)) {
+				frame, _ := msg.MarshalBinary()
+				err = newFrameError(msg.Offset, msg.Channel, frame, ErrUnsynchronized)
+			}
+		}
 
-	if err == ErrChecksum {
-		var b byte
-		b, err = rr.reader.ReadByte()
 		if err == nil {
-			length := channelLengths[Channel(b)]
-			io.ReadFull(rr.reader, make([]byte, length))
-			for b, err = rr.reader.ReadByte(); err == nil; b, err = rr.reader.ReadByte() {
-				if b != 0x00 {
-					return ErrUnexpectedInput
-				}
+			if corruption != nil {
+				err = rr.emit(corruption)
+				corruption = nil
+			}
+
+			if err == nil {
+				_, err = rr.reader.Discard(msg.Length())
+				rr.offset += int64(msg.Length())
+				boundary = rr.offset
+				synchronized = true
+			}
+
+			if err == nil {
+				err = rr.emit(msg)
+			}
+			continue
+		}
+
+		var frameErr *FrameError
+		if !errors.As(err, &frameErr) {
+			break
+		}
+
+		if rr.offset == boundary {
+			if errors.Is(err, ErrChecksum) {
+				rr.checksumErrors++
+			}
+
+			if errors.Is(err, ErrChecksum) || errors.Is(err, ErrUnsynchronized) {
+				boundary += int64(len(frameErr.Data))
 			}
 		}
+
+		if corruption == nil {
+			corruption = &Corruption{Offset: rr.offset, Reason: err, zeros: true}
+		}
+		synchronized = false
+		err = rr.skip(corruption)
+	}
+
+	// the end of a run file is padded with zeros
+	if corruption != nil && !corruption.zeros && (err == nil || err == io.EOF) {
+		err = rr.emit(corruption)
+	}
+
+	if err == nil {
+		err = io.EOF
 	}
+	return err
+}
+
+// Read reads messages from the underlying reader until the end of the
+// input is reached or the context is cancelled.  Corrupted regions of
+// the input are skipped and reported with Corruption samples.  The Output
+// channel is closed when Read returns.  Reaching the end of the input is
+// not considered an error
+func (rr *RunReader) Read(ctx context.Context) error {
+	rr.ctx = ctx
+	err := rr.readFrames()
 	close(rr.messages)
+	if err == io.EOF {
+		err = nil
+	}
 	return err
 }
 
@@ -94,3 +205,11 @@ func (rr *RunReader) Read() error {
 func (rr *RunReader) Output() <-chan Sample {
 	return rr.messages
 }
+
+// ChecksumErrors returns the number of frames that were discarded because
+// their checksum was invalid.  Frames are counted through a corrupted region
+// until a frame with an unknown length is found.  The bytes skipped after
+// that, until the reader is synchronized again, are not counted
+func (rr *RunReader) ChecksumErrors() int {
+	return rr.checksumErrors
+}
@@ -0,0 +1,239 @@
+package dl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// timestampFrames returns the encoded Timestamp frames for the given
+// timestamps.  Each frame is 5 bytes long
+func timestampFrames(timestamps ...TimeOffset) []byte {
+	buf := &bytes.Buffer{}
+	writer := NewRunWriter(buf)
+	for _, timestamp := range timestamps {
+		writer.Write(&Timestamp{Timestamp: timestamp})
+	}
+	writer.Flush()
+	return buf.Bytes()
+}
+
+func concat(bufs ...[]byte) []byte {
+	return bytes.Join(bufs, nil)
+}
+
+func flip(buf []byte, offset int) []byte {
+	buf = append([]byte(nil), buf...)
+	buf[offset] ^= 0x10
+	return buf
+}
+
+func TestRunReaderCorruption(t *testing.T) {
+	garbage := []byte{0xff, 0xfe, 0xfd}
+	frames := timestampFrames(100, 200, 300, 400, 500)
+	all := []TimeOffset{100, 200, 300, 400, 500}
+
+	// a frame with a correct checksum in the middle of a corrupted region
+	fake := concat([]byte{0xff}, timestampFrames(999), []byte{0xfe})
+
+	tests := []struct {
+		name           string
+		input          []byte
+		timestamps     []TimeOffset
+		offsets        []int64
+		lengths        []int64
+		reasons        []error
+		checksumErrors int
+	}{
+		{
+			name:       "clean",
+			input:      frames,
+			timestamps: all,
+		},
+		{
+			name:       "zero padding",
+			input:      concat(frames, make([]byte, 20)),
+			timestamps: all,
+		},
+		{
+			name:       "garbage prefix",
+			input:      concat(garbage, frames),
+			timestamps: all,
+			offsets:    []int64{0},
+			lengths:    []int64{3},
+			reasons:    []error{ErrUnknownLength},
+		},
+		{
+			name:           "bit flip",
+			input:          flip(frames, 12),
+			timestamps:     []TimeOffset{100, 200, 400, 500},
+			offsets:        []int64{10},
+			lengths:        []int64{5},
+			reasons:        []error{ErrChecksum},
+			checksumErrors: 1,
+		},
+		{
+			name:           "bit flip in the first frame",
+			input:          flip(frames, 2),
+			timestamps:     []TimeOffset{200, 300, 400, 500},
+			offsets:        []int64{0},
+			lengths:        []int64{5},
+			reasons:        []error{ErrChecksum},
+			checksumErrors: 1,
+		},
+		{
+			name:       "first frame followed by corruption",
+			input:      concat(frames[:5], garbage, frames[5:]),
+			timestamps: []TimeOffset{200, 300, 400, 500},
+			offsets:    []int64{0},
+			lengths:    []int64{8},
+			reasons:    []error{ErrUnsynchronized},
+		},
+		{
+			name:           "consecutive bit flips",
+			input:          flip(flip(frames, 12), 17),
+			timestamps:     []TimeOffset{100, 200, 500},
+			offsets:        []int64{10},
+			lengths:        []int64{10},
+			reasons:        []error{ErrChecksum},
+			checksumErrors: 2,
+		},
+		{
+			name:           "single good frame between bit flips",
+			input:          flip(flip(frames, 12), 22),
+			timestamps:     []TimeOffset{100, 200},
+			offsets:        []int64{10},
+			lengths:        []int64{15},
+			reasons:        []error{ErrChecksum},
+			checksumErrors: 2,
+		},
+		{
+			name:       "fake frame in corrupted region",
+			input:      concat(frames[:10], fake, frames[10:]),
+			timestamps: all,
+			offsets:    []int64{10},
+			lengths:    []int64{int64(len(fake))},
+			reasons:    []error{ErrUnknownLength},
+		},
+		{
+			name:       "truncated",
+			input:      frames[:len(frames)-2],
+			timestamps: []TimeOffset{100, 200, 300, 400},
+			offsets:    []int64{20},
+			lengths:    []int64{3},
+			reasons:    []error{io.ErrUnexpectedEOF},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := NewRunReader(bytes.NewReader(test.input))
+			chain := NewProcessingChain(context.Background(), reader)
+
+			var timestamps []TimeOffset
+			var corrupted []*Corruption
+			for sample := range chain.Output() {
+				switch v := sample.(type) {
+				case *Message:
+					timestamp := &Timestamp{}
+					timestamp.UnmarshalBinary(v.Data)
+					timestamps = append(timestamps, timestamp.Timestamp)
+				case *Corruption:
+					corrupted = append(corrupted, v)
+				}
+			}
+
+			if err := chain.Wait(); err != nil {
+				t.Fatalf("Wait() returned %v", err)
+			}
+
+			if len(timestamps) != len(test.timestamps) {
+				t.Fatalf("Expected timestamps %v got %v", test.timestamps, timestamps)
+			}
+
+			for i, timestamp := range timestamps {
+				if timestamp != test.timestamps[i] {
+					t.Errorf("Expected timestamps %v got %v", test.timestamps, timestamps)
+					break
+				}
+			}
+
+			if len(corrupted) != len(test.offsets) {
+				t.Fatalf("Expected %d corrupted regions got %v", len(test.offsets), corrupted)
+			}
+
+			for i, corruption := range corrupted {
+				if corruption.Offset != test.offsets[i] || corruption.Length != test.lengths[i] {
+					t.Errorf("Expected %d bytes corrupted at offset %d got %v", test.lengths[i], test.offsets[i], corruption)
+				}
+
+				if !errors.Is(corruption.Reason, test.reasons[i]) {
+					t.Errorf("Expected reason %v got %v", test.reasons[i], corruption.Reason)
+				}
+
+				var frameErr *FrameError
+				if !errors.As(corruption.Reason, &frameErr) || frameErr.Offset != corruption.Offset {
+					t.Errorf("Expected the reason to describe the frame at offset %d got %v", corruption.Offset, corruption.Reason)
+				}
+			}
+
+			if reader.ChecksumErrors() != test.checksumErrors {
+				t.Errorf("Expected %d checksum errors got %d", test.checksumErrors, reader.ChecksumErrors())
+			}
+		})
+	}
+}
+
+func TestRunParserDecodeError(t *testing.T) {
+	// frames on the custom channel are too short for a testSample
+	custom := Channel(200)
+	defer restoreChannel(custom)()
+	RegisterChannel(custom, 3, testFactory)
+
+	frame := []byte{byte(custom), 0x42, byte(custom) + 0x42}
+	input := concat(timestampFrames(100), frame, timestampFrames(200))
+
+	chain := NewProcessingChain(context.Background(), NewRunReader(bytes.NewReader(input)))
+	chain.Append(&RunParser{})
+
+	var timestamps []TimeOffset
+	var decodeErrors []*DecodeError
+	for sample := range chain.Output() {
+		switch v := sample.(type) {
+		case *Timestamp:
+			timestamps = append(timestamps, v.Timestamp)
+		case *DecodeError:
+			decodeErrors = append(decodeErrors, v)
+		default:
+			t.Errorf("Unexpected sample %v", sample)
+		}
+	}
+
+	if err := chain.Wait(); err != nil {
+		t.Fatalf("Wait() returned %v", err)
+	}
+
+	if len(timestamps) != 2 || timestamps[0] != 100 || timestamps[1] != 200 {
+		t.Errorf("Expected timestamps [100 200] got %v", timestamps)
+	}
+
+	if len(decodeErrors) != 1 {
+		t.Fatalf("Expected 1 DecodeError got %v", decodeErrors)
+	}
+
+	decodeErr := decodeErrors[0]
+	if msg := decodeErr.Message; msg.Channel != custom || msg.Offset != 5 {
+		t.Errorf("Expected the %v message at offset 5 got %v at offset %d", custom, msg.Channel, msg.Offset)
+	}
+
+	var frameErr *FrameError
+	if !errors.As(decodeErr, &frameErr) || frameErr.Offset != 5 || frameErr.Channel != custom || !bytes.Equal(frameErr.Data, frame) {
+		t.Errorf("Expected a FrameError for the frame %v at offset 5 got %v", frame, decodeErr.Err)
+	}
+
+	if !errors.Is(decodeErr, ErrShortBuffer) {
+		t.Errorf("Expected the DecodeError to wrap %v got %v", ErrShortBuffer, decodeErr.Err)
+	}
+}
@@ -0,0 +1,86 @@
+package dl
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SampleFactory returns a new, empty, sample that the data of a frame
+// received on the given channel can be unmarshaled into.  The frame's data
+// is supplied so that channels carrying more than one kind of message (such
+// as the Run Status channel) can choose the correct sample.  If the data
+// cannot be decoded then nil is returned
+type SampleFactory func(channel Channel, data []byte) ParseableSample
+
+type channelDecoder struct {
+	length  int
+	factory SampleFactory
+}
+
+var registry = struct {
+	sync.RWMutex
+	channels map[Channel]channelDecoder
+}{channels: make(map[Channel]channelDecoder)}
+
+func init() {
+	for channel, length := range channelLengths {
+		RegisterChannel(channel, length, builtinFactory)
+	}
+}
+
+// RegisterChannel registers the frame length and the sample factory for
+// a data channel.  The length is the length of the whole frame, including
+// the channel and checksum bytes.  Registering a channel that is already
+// registered replaces the existing registration, which allows firmware
+// specific variants of the built in decoders to be supplied.  If the factory
+// is nil then messages on the channel are read but not decoded.
+// RegisterChannel panics if the channel does not fit in a single byte or
+// the length is too short to hold the channel and checksum bytes
+func RegisterChannel(channel Channel, length int, factory SampleFactory) {
+	if channel < 0 || channel > 0xff {
+		panic(fmt.Sprintf("dl: invalid channel %d", int(channel)))
+	}
+
+	if length < 2 {
+		panic(fmt.Sprintf("dl: invalid frame length %d for channel %v", length, channel))
+	}
+
+	registry.Lock()
+	registry.channels[channel] = channelDecoder{length: length, factory: factory}
+	registry.Unlock()
+}
+
+// ChannelLength returns the frame length registered for the channel
+func ChannelLength(channel Channel) (length int, found bool) {
+	registry.RLock()
+	decoder, found := registry.channels[channel]
+	registry.RUnlock()
+	return decoder.length, found
+}
+
+// Channels returns all of the registered channels ordered by channel number
+func Channels() []Channel {
+	registry.RLock()
+	channels := make([]Channel, 0, len(registry.channels))
+	for channel := range registry.channels {
+		channels = append(channels, channel)
+	}
+	registry.RUnlock()
+
+	sort.Slice(channels, func(i, j int) bool { return channels[i] < channels[j] })
+	return channels
+}
+
+// sampleFactory returns a new sample for the channel from the registered
+// SampleFactory.  nil is returned if the channel has no factory
+func sampleFactory(channel Channel, buf []byte) ParseableSample {
+	registry.RLock()
+	decoder := registry.channels[channel]
+	registry.RUnlock()
+
+	if decoder.factory == nil {
+		return nil
+	}
+	return decoder.factory(channel, buf)
+}
@@ -0,0 +1,139 @@
+package dl
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// testSample is a sample decoded by a custom SampleFactory
+type testSample struct {
+	channel Channel
+	value   int
+}
+
+func (*testSample) Type() string { return "testSample" }
+
+func (ts *testSample) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 2)
+	if err == nil {
+		ts.value = int(buf[0])<<8 | int(buf[1])
+	}
+	return err
+}
+
+func testFactory(channel Channel, data []byte) ParseableSample { return &testSample{channel: channel} }
+
+// restoreChannel returns a function that restores the registration of the
+// channel to what it is now
+func restoreChannel(channel Channel) func() {
+	registry.RLock()
+	decoder, found := registry.channels[channel]
+	registry.RUnlock()
+
+	return func() {
+		registry.Lock()
+		if found {
+			registry.channels[channel] = decoder
+		} else {
+			delete(registry.channels, channel)
+		}
+		registry.Unlock()
+	}
+}
+
+func TestRegisterChannel(t *testing.T) {
+	custom := Channel(200)
+	defer restoreChannel(custom)()
+
+	if _, found := ChannelLength(custom); found {
+		t.Fatalf("expected %v to be unregistered", custom)
+	}
+
+	RegisterChannel(custom, 4, testFactory)
+	if length, found := ChannelLength(custom); !found || length != 4 {
+		t.Errorf("expected length 4 got %d (%v)", length, found)
+	}
+
+	found := false
+	for _, channel := range Channels() {
+		found = found || channel == custom
+	}
+
+	if !found {
+		t.Errorf("expected Channels() to include %v", custom)
+	}
+
+	sample, err := newMessage(custom, []byte{0x12, 0x34}).Decode()
+	if v, ok := sample.(*testSample); err != nil || !ok || v.channel != custom || v.value != 0x1234 {
+		t.Errorf("expected a testSample with value 0x1234 got %v (%v)", sample, err)
+	}
+
+	// the reader uses the registered length to frame the custom channel
+	input := concat([]byte{byte(custom), 0x12, 0x34, byte(custom) + 0x12 + 0x34}, timestampFrames(100))
+	chain := NewProcessingChain(context.Background(), NewRunReader(bytes.NewReader(input)))
+	var channels []Channel
+	for sample := range chain.Output() {
+		if msg, ok := sample.(*Message); ok {
+			channels = append(channels, msg.Channel)
+		}
+	}
+
+	if err := chain.Wait(); err != nil {
+		t.Fatalf("Wait() returned %v", err)
+	}
+
+	if len(channels) != 2 || channels[0] != custom || channels[1] != TimestampChannel {
+		t.Errorf("expected %v and %v messages got %v", custom, TimestampChannel, channels)
+	}
+
+	RegisterChannel(custom, 4, nil)
+	if sample, err := newMessage(custom, []byte{0x12, 0x34}).Decode(); sample != nil || err != nil {
+		t.Errorf("expected no sample without a factory got %v (%v)", sample, err)
+	}
+}
+
+func TestRegisterChannelOverride(t *testing.T) {
+	restore := restoreChannel(SpeedDataChannel)
+	length, _ := ChannelLength(SpeedDataChannel)
+	data := []byte{0x00, 0x00, 0x03, 0xe8, 0x00, 0x00, 0x00, 0x00}
+
+	RegisterChannel(SpeedDataChannel, length, testFactory)
+	if sample, err := newMessage(SpeedDataChannel, data).Decode(); err != nil {
+		t.Errorf("Decode() returned %v", err)
+	} else if _, ok := sample.(*testSample); !ok {
+		t.Errorf("expected the override to decode a testSample got %T", sample)
+	}
+
+	restore()
+	if sample, err := newMessage(SpeedDataChannel, data).Decode(); err != nil {
+		t.Errorf("Decode() returned %v", err)
+	} else if _, ok := sample.(*SpeedData); !ok {
+		t.Errorf("expected the built in decoder to be restored got %T", sample)
+	}
+}
+
+func TestRegisterChannelPanics(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel Channel
+		length  int
+	}{
+		{"negative channel", -1, 4},
+		{"channel too large", 256, 4},
+		{"no checksum", Channel(200), 1},
+		{"negative length", Channel(200), -1},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer restoreChannel(test.channel)()
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected RegisterChannel to panic", test.name)
+				}
+			}()
+			RegisterChannel(test.channel, test.length, testFactory)
+		}()
+	}
+}
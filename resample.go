@@ -0,0 +1,139 @@
+package dl
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// lerp linearly interpolates between a and b
+func lerp(a, b, fraction float64) float64 { return a + fraction*(b-a) }
+
+// lerpHeading linearly interpolates between two headings, taking the
+// shortest direction around the compass
+func lerpHeading(a, b Heading, fraction float64) Heading {
+	delta := math.Mod(float64(b-a)+540, 360) - 180
+	return Heading(math.Mod(lerp(float64(a), float64(a)+delta, fraction)+360, 360))
+}
+
+// interpolateEpoch returns a new Epoch with continuous values linearly
+// interpolated between prev and cur.  The heading and yaw angle are
+// interpolated the shortest way around the compass.  Discrete values, such
+// as the lap number and the accuracies, are held from prev
+func interpolateEpoch(prev, cur *Epoch, fraction float64) *Epoch {
+	epoch := copyEpoch(prev)
+	if !prev.Time.IsZero() && !cur.Time.IsZero() {
+		epoch.Time = prev.Time.Add(time.Duration(fraction * float64(cur.Time.Sub(prev.Time))))
+	}
+
+	epoch.Distance = Distance(lerp(float64(prev.Distance), float64(cur.Distance), fraction))
+	if prev.Lap == cur.Lap {
+		epoch.LapDistance = Distance(lerp(float64(prev.LapDistance), float64(cur.LapDistance), fraction))
+		epoch.LapTime = TimeOffset(math.Round(lerp(float64(prev.LapTime), float64(cur.LapTime), fraction)))
+		epoch.TimeSlip = TimeOffset(math.Round(lerp(float64(prev.TimeSlip), float64(cur.TimeSlip), fraction)))
+	}
+
+	epoch.GPSTime = GPSTime(math.Round(lerp(float64(prev.GPSTime), float64(cur.GPSTime), fraction)))
+	epoch.Speed = Speed(lerp(float64(prev.Speed), float64(cur.Speed), fraction))
+	epoch.LateralAcceleration = Acceleration(lerp(float64(prev.LateralAcceleration), float64(cur.LateralAcceleration), fraction))
+	epoch.LongitudinalAcceleration = Acceleration(lerp(float64(prev.LongitudinalAcceleration), float64(cur.LongitudinalAcceleration), fraction))
+	epoch.VectorAcceleration = Acceleration(lerp(float64(prev.VectorAcceleration), float64(cur.VectorAcceleration), fraction))
+	epoch.VerticalAcceleration = Acceleration(lerp(float64(prev.VerticalAcceleration), float64(cur.VerticalAcceleration), fraction))
+	epoch.YawRate = AngularRate(lerp(float64(prev.YawRate), float64(cur.YawRate), fraction))
+	epoch.Yaw = Angle(lerpHeading(Heading(prev.Yaw), Heading(cur.Yaw), fraction))
+	epoch.PitchRate = AngularRate(lerp(float64(prev.PitchRate), float64(cur.PitchRate), fraction))
+	epoch.Pitch = Angle(lerp(float64(prev.Pitch), float64(cur.Pitch), fraction))
+	epoch.RollRate = AngularRate(lerp(float64(prev.RollRate), float64(cur.RollRate), fraction))
+	epoch.Roll = Angle(lerp(float64(prev.Roll), float64(cur.Roll), fraction))
+	epoch.Gradient = Angle(lerp(float64(prev.Gradient), float64(cur.Gradient), fraction))
+	epoch.Heading = lerpHeading(prev.Heading, cur.Heading, fraction)
+	epoch.Latitude = Coordinate(lerp(float64(prev.Latitude), float64(cur.Latitude), fraction))
+	epoch.Longitude = Coordinate(lerp(float64(prev.Longitude), float64(cur.Longitude), fraction))
+	epoch.Altitude = Altitude(math.Round(lerp(float64(prev.Altitude), float64(cur.Altitude), fraction)))
+	epoch.EngineSpeed = EngineSpeed(lerp(float64(prev.EngineSpeed), float64(cur.EngineSpeed), fraction))
+
+	for channel, voltage := range prev.AnalogInputs {
+		if next, found := cur.AnalogInputs[channel]; found {
+			epoch.AnalogInputs[channel] = Voltage(math.Round(lerp(float64(voltage), float64(next), fraction)))
+		}
+	}
+
+	for channel, frequency := range prev.FrequencyInputs {
+		if next, found := cur.FrequencyInputs[channel]; found {
+			epoch.FrequencyInputs[channel] = Frequency(lerp(float64(frequency), float64(next), fraction))
+		}
+	}
+
+	for name, measurement := range prev.Measurements {
+		if next, found := cur.Measurements[name]; found {
+			epoch.Measurements[name] = Measurement{Value: lerp(measurement.Value, next.Value, fraction), Unit: measurement.Unit}
+		}
+	}
+	return epoch
+}
+
+// ResampleAnalyzer converts the irregularly spaced Epochs produced by a
+// SampleDemuxer into Epochs at a fixed rate.  Continuous values, such as
+// speed, position, accelerations and input voltages, are linearly
+// interpolated between the surrounding Epochs while discrete values are
+// held from the preceding Epoch.  Samples other than Epochs are passed to
+// the output unchanged.  This includes Laps and Sectors, whose Epochs are
+// still the original Epochs rather than the resampled ones.  To build laps
+// from resampled Epochs, place the ResampleAnalyzer before the
+// SectorAnalyzer in the processing chain
+type ResampleAnalyzer struct {
+	rate float64
+}
+
+// NewResampleAnalyzer returns a ResampleAnalyzer that will output Epochs
+// at the given rate (in hertz)
+func NewResampleAnalyzer(rate float64) *ResampleAnalyzer {
+	return &ResampleAnalyzer{rate: rate}
+}
+
+// Process will start the resampling loop.  This should usually be run in
+// a go routine
+func (ra *ResampleAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	if ra.rate <= 0 {
+		return ErrInvalidRate
+	}
+
+	var prev *Epoch
+	var origin, next TimeOffset
+	samples := 0
+	for sample := range input {
+		if cur, ok := sample.(*Epoch); ok {
+			if prev == nil {
+				origin, next = cur.Stop, cur.Stop
+				prev = cur
+			}
+
+			for next <= cur.Stop {
+				var epoch *Epoch
+				if next == cur.Stop {
+					epoch = copyEpoch(cur)
+				} else {
+					epoch = interpolateEpoch(prev, cur, float64(next-prev.Stop)/float64(cur.Stop-prev.Stop))
+				}
+
+				if samples > 0 {
+					epoch.Start = origin + TimeOffset(math.Round(float64(samples-1)*1000/ra.rate))
+				}
+				epoch.Stop = next
+
+				if err := send(ctx, output, epoch); err != nil {
+					return err
+				}
+				samples++
+				next = origin + TimeOffset(math.Round(float64(samples)*1000/ra.rate))
+			}
+			prev = cur
+			continue
+		}
+
+		if err := send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package dl
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestInterpolateEpoch(t *testing.T) {
+	tests := []struct {
+		prevHeading, curHeading float64
+		fraction                float64
+		expected                float64
+	}{
+		{10, 30, 0.5, 20},
+		{350, 10, 0.5, 0},
+		{350, 10, 0.25, 355},
+		{10, 350, 0.75, 355},
+		{90, 270, 0, 90},
+		{180, 190, 1, 190},
+	}
+
+	for _, test := range tests {
+		prev := &Epoch{Heading: Heading(test.prevHeading), Yaw: Angle(test.prevHeading), Speed: 10}
+		cur := &Epoch{Heading: Heading(test.curHeading), Yaw: Angle(test.curHeading), Speed: 20}
+		epoch := interpolateEpoch(prev, cur, test.fraction)
+
+		if math.Abs(float64(epoch.Heading)-test.expected) > 1e-9 {
+			t.Errorf("%v -> %v at %v: expected heading %v got %v", test.prevHeading, test.curHeading, test.fraction, test.expected, epoch.Heading)
+		}
+
+		if math.Abs(float64(epoch.Yaw)-test.expected) > 1e-9 {
+			t.Errorf("%v -> %v at %v: expected yaw %v got %v", test.prevHeading, test.curHeading, test.fraction, test.expected, epoch.Yaw)
+		}
+
+		if speed := 10 + 10*test.fraction; math.Abs(float64(epoch.Speed)-speed) > 1e-9 {
+			t.Errorf("%v: expected speed %v got %v", test.fraction, speed, epoch.Speed)
+		}
+	}
+}
+
+func TestResampleAnalyzer(t *testing.T) {
+	lap := &Lap{Number: 1, Start: 0, Stop: 250}
+	samples := []Sample{
+		&Epoch{Stop: 0, Speed: 0, Yaw: 350},
+		&Epoch{Stop: 100, Speed: 10, Yaw: 10},
+		&Epoch{Stop: 250, Speed: 40, Yaw: 40},
+		lap,
+	}
+
+	tests := []struct {
+		rate  float64
+		stops []TimeOffset
+		speed []Speed
+		yaw   []Angle
+	}{
+		{20, []TimeOffset{0, 50, 100, 150, 200, 250}, []Speed{0, 5, 10, 20, 30, 40}, []Angle{350, 0, 10, 20, 30, 40}},
+		{10, []TimeOffset{0, 100, 200}, []Speed{0, 10, 30}, []Angle{350, 10, 30}},
+		{8, []TimeOffset{0, 125, 250}, []Speed{0, 15, 40}, []Angle{350, 15, 40}},
+	}
+
+	for _, test := range tests {
+		output := runAnalyzers(t, samples, NewResampleAnalyzer(test.rate))
+		var epochs []*Epoch
+		var laps []*Lap
+		for _, sample := range output {
+			switch v := sample.(type) {
+			case *Epoch:
+				epochs = append(epochs, v)
+			case *Lap:
+				laps = append(laps, v)
+			}
+		}
+
+		if len(epochs) != len(test.stops) {
+			t.Errorf("%vHz: expected %d epochs got %d", test.rate, len(test.stops), len(epochs))
+			continue
+		}
+
+		for i, epoch := range epochs {
+			if epoch.Stop != test.stops[i] {
+				t.Errorf("%vHz epoch %d: expected stop %v got %v", test.rate, i, test.stops[i], epoch.Stop)
+			}
+
+			if math.Abs(float64(epoch.Speed-test.speed[i])) > 1e-9 {
+				t.Errorf("%vHz epoch %d: expected speed %v got %v", test.rate, i, test.speed[i], epoch.Speed)
+			}
+
+			if math.Abs(float64(epoch.Yaw-test.yaw[i])) > 1e-9 {
+				t.Errorf("%vHz epoch %d: expected yaw %v got %v", test.rate, i, test.yaw[i], epoch.Yaw)
+			}
+		}
+
+		if len(laps) != 1 || laps[0] != lap {
+			t.Errorf("%vHz: expected the lap to be passed through unchanged got %v", test.rate, laps)
+		}
+	}
+
+	if err := NewResampleAnalyzer(0).Process(context.Background(), nil, nil); err != ErrInvalidRate {
+		t.Errorf("expected %v got %v", ErrInvalidRate, err)
+	}
+}
@@ -2,7 +2,9 @@
 package dl
 
 import (
+	"encoding/hex"
 	"fmt"
+	"math"
 
 	"gonum.org/v1/gonum/unit"
 )
@@ -89,6 +91,9 @@ type StartStopInfo struct {
 
 	// LowestBuffer for the logging session
 	LowestBuffer int
+
+	// raw is the data the status was decoded from
+	raw []byte
 }
 
 // Type returns the Sample Type, in this case "StartStopInfo"
@@ -119,10 +124,42 @@ func (ssm *StartStopInfo) UnmarshalBinary(buf []byte) (err error) {
 		ssm.AutoStartSource = AutoMethod(buf[5])
 		ssm.AutoStopSource = AutoMethod(buf[6])
 		ssm.LowestBuffer = int(buf[7])<<8 | int(buf[8])
+		ssm.raw = append([]byte(nil), buf...)
 	}
 	return err
 }
 
+// DataChannel returns the data channel for StartStopInfo (RunStatusChannel)
+func (*StartStopInfo) DataChannel() Channel { return RunStatusChannel }
+
+// MarshalBinary encodes the StartStopInfo into the data portion of a
+// RunStatusChannel message.  A StartStopInfo that was decoded from the
+// packed (0x04) format is encoded in the same format
+func (ssm *StartStopInfo) MarshalBinary() ([]byte, error) {
+	buf := undecoded(ssm.raw, 9)
+	if buf[0] == 0x04 {
+		if ssm.StartMethod > 0x0f || ssm.StopMethod > 0x0f {
+			return nil, newParseError(fmt.Sprintf("Start method 0x%02x and stop method 0x%02x cannot be packed", int(ssm.StartMethod), int(ssm.StopMethod)))
+		}
+		buf[1] = byte(ssm.StartMethod)<<4 | byte(ssm.StopMethod)
+	} else {
+		if ssm.StartMethod > PreTriggerLoop {
+			return nil, newParseError(fmt.Sprintf("Unknown start method 0x%02x", int(ssm.StartMethod)))
+		}
+		buf[0] = byte(ssm.StartMethod)
+		buf[1] = byte(ssm.StopMethod)
+	}
+
+	buf[2] = byte(ssm.PreTriggerLoopMethod)
+	buf[3] = byte(math.Round(float64(ssm.PreTriggerTime / unit.Centisecond)))
+	buf[4] = byte(math.Round(float64(ssm.PostTriggerTime / unit.Centisecond)))
+	buf[5] = byte(ssm.AutoStartSource)
+	buf[6] = byte(ssm.AutoStopSource)
+	buf[7] = byte(ssm.LowestBuffer >> 8)
+	buf[8] = byte(ssm.LowestBuffer)
+	return buf, nil
+}
+
 // TrackMarkerFailureCode indicates the reason the data logger failed to add
 // a track marker
 type TrackMarkerFailureCode int
@@ -143,6 +180,9 @@ const (
 type TrackMarkerFailureMessage struct {
 	// Code indicates why the data logger failed to add a track marker
 	Code TrackMarkerFailureCode
+
+	// raw is the data the message was decoded from
+	raw []byte
 }
 
 // Type returns the Sample Type, in this case "TrackMarkerFailureMessage"
@@ -155,10 +195,25 @@ func (tmfm *TrackMarkerFailureMessage) UnmarshalBinary(buf []byte) (err error) {
 	err = checkBufLen(buf, 2)
 	if err == nil {
 		tmfm.Code = TrackMarkerFailureCode(buf[1])
+		tmfm.raw = append([]byte(nil), buf...)
 	}
 	return err
 }
 
+// DataChannel returns the data channel for a TrackMarkerFailureMessage
+// (RunStatusChannel)
+func (*TrackMarkerFailureMessage) DataChannel() Channel { return RunStatusChannel }
+
+// MarshalBinary encodes the TrackMarkerFailureMessage into the data portion
+// of a RunStatusChannel message.  The trailing bytes that are not decoded
+// are written back as they were received
+func (tmfm *TrackMarkerFailureMessage) MarshalBinary() ([]byte, error) {
+	buf := undecoded(tmfm.raw, 9)
+	buf[0] = 0x05
+	buf[1] = byte(tmfm.Code)
+	return buf, nil
+}
+
 // Status contains information about the data logger status. Status
 // messages are received on data channel 2 (Run Status Messages)
 type Status struct {
@@ -185,6 +240,9 @@ type Status struct {
 
 	// INSConverged
 	INSConverged bool
+
+	// raw is the data the status was decoded from
+	raw []byte
 }
 
 // Type returns the Sample Type, in this case "Status"
@@ -194,13 +252,85 @@ func (*Status) Type() string { return "Status" }
 // the parsed values to the Status. BufError is returned
 // if the input buffer is too short to process
 func (sm *Status) UnmarshalBinary(buf []byte) (err error) {
-	sm.GPSDetected = buf[1]&0x80 == 0x80
-	sm.IMUDetected = buf[1]&0x40 == 0x40
-	sm.GPS1Lock = buf[1]&0x20 == 0x20
-	sm.GPS2Lock = buf[1]&0x10 == 0x10
-	sm.CarrierLock = buf[1]&0x08 == 0x08
-	sm.RTKLock = buf[1]&0x04 == 0x04
-	sm.INSInitialized = buf[1]&0x02 == 0x02
-	sm.INSConverged = buf[1]&0x01 == 0x01
-	return nil
+	err = checkBufLen(buf, 2)
+	if err == nil {
+		sm.GPSDetected = buf[1]&0x80 == 0x80
+		sm.IMUDetected = buf[1]&0x40 == 0x40
+		sm.GPS1Lock = buf[1]&0x20 == 0x20
+		sm.GPS2Lock = buf[1]&0x10 == 0x10
+		sm.CarrierLock = buf[1]&0x08 == 0x08
+		sm.RTKLock = buf[1]&0x04 == 0x04
+		sm.INSInitialized = buf[1]&0x02 == 0x02
+		sm.INSConverged = buf[1]&0x01 == 0x01
+		sm.raw = append([]byte(nil), buf...)
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a Status (RunStatusChannel)
+func (*Status) DataChannel() Channel { return RunStatusChannel }
+
+// MarshalBinary encodes the Status into the data portion of a
+// RunStatusChannel message.  The trailing bytes that are not decoded
+// are written back as they were received
+func (sm *Status) MarshalBinary() ([]byte, error) {
+	buf := undecoded(sm.raw, 9)
+	buf[0] = 0x06
+	buf[1] = 0
+	for i, flag := range []bool{sm.GPSDetected, sm.IMUDetected, sm.GPS1Lock, sm.GPS2Lock, sm.CarrierLock, sm.RTKLock, sm.INSInitialized, sm.INSConverged} {
+		if flag {
+			buf[1] |= 0x80 >> uint(i)
+		}
+	}
+	return buf, nil
+}
+
+// RunInformation is the header written by the data logger at the start of
+// each run on data channel 1 (Run Information).  The layout of the header
+// is not documented, so the header is kept as the raw bytes that were
+// received.  Comparing headers tells apart runs that were recorded on
+// different loggers or firmware
+type RunInformation struct {
+	// Data is the undecoded run header
+	Data []byte
+}
+
+// Type returns the Sample Type, in this case "RunInformation"
+func (*RunInformation) Type() string { return "RunInformation" }
+
+// String returns the run header as hex bytes
+func (ri *RunInformation) String() string {
+	return hexDump(ri.Data)
+}
+
+// MarshalText encodes the run header as a hex string
+func (ri *RunInformation) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(ri.Data)), nil
+}
+
+// UnmarshalText decodes a run header encoded by MarshalText
+func (ri *RunInformation) UnmarshalText(text []byte) (err error) {
+	ri.Data, err = hex.DecodeString(string(text))
+	return err
+}
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the RunInformation. BufError is returned
+// if the input buffer is too short to process
+func (ri *RunInformation) UnmarshalBinary(buf []byte) (err error) {
+	err = checkBufLen(buf, 7)
+	if err == nil {
+		ri.Data = append([]byte(nil), buf...)
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a RunInformation
+// (RunInformationChannel)
+func (*RunInformation) DataChannel() Channel { return RunInformationChannel }
+
+// MarshalBinary encodes the RunInformation into the data portion of a
+// RunInformationChannel message
+func (ri *RunInformation) MarshalBinary() ([]byte, error) {
+	return undecoded(ri.Data, 7), nil
 }
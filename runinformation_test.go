@@ -0,0 +1,39 @@
+package dl
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestRunInformation(t *testing.T) {
+	data := []byte{0x0b, 0x03, 0x07, 0x12, 0x34, 0x00, 0x2a}
+	runInfo := &RunInformation{}
+	if err := runInfo.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() returned %v", err)
+	}
+
+	// the header is copied, not referenced
+	data[0] = 0
+	if runInfo.Data[0] != 0x0b {
+		t.Errorf("Expected the header to be copied")
+	}
+
+	if str := runInfo.String(); str != "0x0b, 0x03, 0x07, 0x12, 0x34, 0x00, 0x2a" {
+		t.Errorf("Unexpected string %q", str)
+	}
+
+	buf, _ := json.Marshal(struct{ RunInformation *RunInformation }{runInfo})
+	if string(buf) != `{"RunInformation":"0b03071234002a"}` {
+		t.Errorf("Unexpected JSON %s", buf)
+	}
+
+	encoded, _ := runInfo.MarshalBinary()
+	if !bytes.Equal(encoded, runInfo.Data) {
+		t.Errorf("Expected %v got %v", runInfo.Data, encoded)
+	}
+
+	if err := runInfo.UnmarshalBinary(data[:6]); err == nil {
+		t.Errorf("Expected an error for a short header")
+	}
+}
@@ -23,6 +23,16 @@ type ParseableSample interface {
 	encoding.BinaryUnmarshaler
 }
 
+// MarshalableSample is a sample that can be encoded back into the
+// data portion of a Message
+type MarshalableSample interface {
+	Sample
+	encoding.BinaryMarshaler
+
+	// DataChannel returns the data channel that the sample is sent on
+	DataChannel() Channel
+}
+
 // LapMarker is a position to indicate the beginning of a sector
 type LapMarker struct {
 	// Marker is the marker number
@@ -36,6 +46,9 @@ type LapMarker struct {
 
 	// Heading is the direction the marker is pointing
 	Heading Heading
+
+	// raw is the data the marker was decoded from
+	raw []byte
 }
 
 // Type returns the Sample Type, in this case "LapMarker"
@@ -45,17 +58,70 @@ func (*LapMarker) Type() string { return "LapMarker" }
 // the parsed values to the LapMarker. BufError is returned
 // if the input buffer is too short to process
 func (lm *LapMarker) UnmarshalBinary(buf []byte) error {
-	err := checkBufLen(buf, 16)
+	err := checkBufLen(buf, 13)
 	if err == nil {
 		lm.Marker = int(buf[0])
 		lm.Latitude = Coordinate(computeGeo(buf[1:5])) * 0.0000001
-		lm.Longitude = Coordinate(computeGeo(buf[6:10])) * 0.0000001
-		lm.Heading = Heading(computeGeo(buf[11:15])) * 0.00001
+		lm.Longitude = Coordinate(computeGeo(buf[5:9])) * 0.0000001
+		lm.Heading = Heading(computeGeo(buf[9:13])) * 0.00001
+		lm.raw = append([]byte(nil), buf...)
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a LapMarker (LapMarkerChannel)
+func (*LapMarker) DataChannel() Channel { return LapMarkerChannel }
+
+// MarshalBinary encodes the LapMarker into the data portion of a
+// LapMarkerChannel message.  The trailing bytes that are not decoded
+// are written back as they were received
+func (lm *LapMarker) MarshalBinary() ([]byte, error) {
+	buf := undecoded(lm.raw, 19)
+	buf[0] = byte(lm.Marker)
+	copy(buf[1:5], encodeGeo(float64(lm.Latitude)/0.0000001))
+	copy(buf[5:9], encodeGeo(float64(lm.Longitude)/0.0000001))
+	copy(buf[9:13], encodeGeo(float64(lm.Heading)/0.00001))
+	return buf, nil
+}
+
+// SectorTime is the sector time calculated by the data logger itself and
+// received on the New Sector Time channel (data channel 4).  The layout of
+// the frame has not been confirmed against a published specification.  The
+// first byte is taken to be the marker number and the next four bytes the
+// time in milliseconds, big endian like the other 32 bit values
+type SectorTime struct {
+	// Sector is the number of the marker that closed the sector
+	Sector int
+
+	// Time is the time taken to complete the sector, as measured by
+	// the data logger
+	Time TimeOffset
+}
+
+// Type returns the Sample Type, in this case "SectorTime"
+func (*SectorTime) Type() string { return "SectorTime" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the SectorTime. BufError is returned
+// if the input buffer is too short to process
+func (st *SectorTime) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 5)
+	if err == nil {
+		st.Sector = int(buf[0])
+		st.Time = TimeOffset(buf[1])<<24 | TimeOffset(buf[2])<<16 | TimeOffset(buf[3])<<8 | TimeOffset(buf[4])
 	}
-	println(hexDump(buf))
 	return err
 }
 
+// DataChannel returns the data channel for a SectorTime (NewSectorTime)
+func (*SectorTime) DataChannel() Channel { return NewSectorTime }
+
+// MarshalBinary encodes the SectorTime into the data portion of a
+// NewSectorTime message
+func (st *SectorTime) MarshalBinary() ([]byte, error) {
+	return append([]byte{byte(st.Sector)}, encodeUint32(uint32(st.Time))...), nil
+}
+
 // LoggerStorage represents data received on the Logger Storage channel
 // (data channel 6)
 type LoggerStorage struct {
@@ -85,6 +151,15 @@ func (ls *LoggerStorage) UnmarshalBinary(buf []byte) error {
 	return err
 }
 
+// DataChannel returns the data channel for a LoggerStorage (LoggerStorageChannel)
+func (*LoggerStorage) DataChannel() Channel { return LoggerStorageChannel }
+
+// MarshalBinary encodes the LoggerStorage into the data portion of a
+// LoggerStorageChannel message
+func (ls *LoggerStorage) MarshalBinary() ([]byte, error) {
+	return []byte{byte(ls.SerialNumber >> 8), byte(ls.SerialNumber), byte(ls.SoftwareVersion), byte(ls.BootloadVersion)}, nil
+}
+
 // GPSTimeStorage contains the data received on the GPS Time Storage channel
 // (data channel 7)
 type GPSTimeStorage struct {
@@ -107,6 +182,15 @@ func (time *GPSTimeStorage) UnmarshalBinary(buf []byte) error {
 	return err
 }
 
+// DataChannel returns the data channel for a GPSTimeStorage (GPSTimeStorageChannel)
+func (*GPSTimeStorage) DataChannel() Channel { return GPSTimeStorageChannel }
+
+// MarshalBinary encodes the GPSTimeStorage into the data portion of a
+// GPSTimeStorageChannel message
+func (time *GPSTimeStorage) MarshalBinary() ([]byte, error) {
+	return encodeUint32(uint32(time.Time)), nil
+}
+
 // Accelerations contains the accelerometer data received on
 // data channel 8
 type Accelerations struct {
@@ -132,6 +216,15 @@ func (accel *Accelerations) UnmarshalBinary(buf []byte) error {
 	return err
 }
 
+// DataChannel returns the data channel for Accelerations (AccelerationsChannel)
+func (*Accelerations) DataChannel() Channel { return AccelerationsChannel }
+
+// MarshalBinary encodes the Accelerations into the data portion of an
+// AccelerationsChannel message
+func (accel *Accelerations) MarshalBinary() ([]byte, error) {
+	return append(encodeAcceleration(accel.Lateral), encodeAcceleration(accel.Longitudinal)...), nil
+}
+
 // Vector is the square root of the sum of squares for lateral
 // and longitudinal acceleration thus producing an acceleration
 // vector in the X and Y axis
@@ -165,6 +258,17 @@ func (ts *Timestamp) UnmarshalBinary(buf []byte) error {
 	return err
 }
 
+// DataChannel returns the data channel for a Timestamp (TimestampChannel)
+func (*Timestamp) DataChannel() Channel { return TimestampChannel }
+
+// MarshalBinary encodes the Timestamp into the data portion of a
+// TimestampChannel message
+func (ts *Timestamp) MarshalBinary() ([]byte, error) {
+	// convert milliseconds to centiseconds
+	value := ts.Timestamp / 10
+	return []byte{byte(value >> 16), byte(value >> 8), byte(value)}, nil
+}
+
 // GPSPosition contains information sent on data channel 10
 type GPSPosition struct {
 	// Latitude (north/south) position in degrees
@@ -193,6 +297,19 @@ func (gpsPos *GPSPosition) UnmarshalBinary(buf []byte) error {
 	return err
 }
 
+// DataChannel returns the data channel for a GPSPosition (GPSPositionChannel)
+func (*GPSPosition) DataChannel() Channel { return GPSPositionChannel }
+
+// MarshalBinary encodes the GPSPosition into the data portion of a
+// GPSPositionChannel message
+func (gpsPos *GPSPosition) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 12)
+	buf = append(buf, encodeGeo(float64(gpsPos.Latitude)/0.0000001)...)
+	buf = append(buf, encodeGeo(float64(gpsPos.Longitude)/0.0000001)...)
+	buf = append(buf, encodeUint32(uint32(gpsPos.Accuracy))...)
+	return buf, nil
+}
+
 // SpeedData contains the information received in data channel 11
 type SpeedData struct {
 	// Speed in meters/second
@@ -219,6 +336,16 @@ func (speed *SpeedData) UnmarshalBinary(buf []byte) error {
 	return err
 }
 
+// DataChannel returns the data channel for SpeedData (SpeedDataChannel)
+func (*SpeedData) DataChannel() Channel { return SpeedDataChannel }
+
+// MarshalBinary encodes the SpeedData into the data portion of a
+// SpeedDataChannel message
+func (speed *SpeedData) MarshalBinary() ([]byte, error) {
+	buf := encodeUint32(uint32(math.Round(float64(speed.Speed) / 0.01)))
+	return append(buf, encodeUint32(uint32(speed.Accuracy))...), nil
+}
+
 // BeaconPulse is also sometimes referred to as Padding and is
 // sent on data channel 12
 type BeaconPulse struct {
@@ -240,6 +367,15 @@ func (beacon *BeaconPulse) UnmarshalBinary(buf []byte) error {
 	return err
 }
 
+// DataChannel returns the data channel for a BeaconPulse (BeaconPulseChannel)
+func (*BeaconPulse) DataChannel() Channel { return BeaconPulseChannel }
+
+// MarshalBinary encodes the BeaconPulse into the data portion of a
+// BeaconPulseChannel message
+func (beacon *BeaconPulse) MarshalBinary() ([]byte, error) {
+	return []byte{beacon.Data}, nil
+}
+
 // FrequencyInput contains information sent on data channels
 // 14-17 representing measured values on the frequency
 // input pins of the data logger
@@ -267,11 +403,28 @@ func (freq *FrequencyInput) UnmarshalBinary(buf []byte) error {
 		time := float64(value) * 1.66666666666667E-07
 
 		// frequency (in herz - 1s / period)
-		freq.Frequency = Frequency(1 / time)
+		freq.Frequency = 0
+		if time > 0 {
+			freq.Frequency = Frequency(1 / time)
+		}
 	}
 	return err
 }
 
+// DataChannel returns the data channel that the FrequencyInput was
+// reported on
+func (freq *FrequencyInput) DataChannel() Channel { return freq.Channel }
+
+// MarshalBinary encodes the FrequencyInput into the data portion of a
+// FrequencyChannel1-FrequencyChannel5 message
+func (freq *FrequencyInput) MarshalBinary() ([]byte, error) {
+	value := 0
+	if freq.Frequency > 0 {
+		value = int(math.Round(1 / (float64(freq.Frequency) * 1.66666666666667E-07)))
+	}
+	return []byte{byte(value >> 16), byte(value >> 8), byte(value)}, nil
+}
+
 // AnalogInput contains voltage readings from the analog inputs
 // on the data logger (data channels 20-51)
 type AnalogInput struct {
@@ -297,6 +450,16 @@ func (analog *AnalogInput) UnmarshalBinary(buf []byte) error {
 	return err
 }
 
+// DataChannel returns the data channel that the AnalogInput was
+// reported on
+func (analog *AnalogInput) DataChannel() Channel { return analog.Channel }
+
+// MarshalBinary encodes the AnalogInput into the data portion of an
+// AnalogChannel1-AnalogChannel32 message
+func (analog *AnalogInput) MarshalBinary() ([]byte, error) {
+	return []byte{byte(analog.Voltage >> 8), byte(analog.Voltage)}, nil
+}
+
 // DateStorage contains the date and time received in data channel
 // 55
 type DateStorage struct {
@@ -328,6 +491,27 @@ func (date *DateStorage) UnmarshalBinary(buf []byte) error {
 	return err
 }
 
+// DataChannel returns the data channel for a DateStorage (DateStorageChannel)
+func (*DateStorage) DataChannel() Channel { return DateStorageChannel }
+
+// MarshalBinary encodes the DateStorage into the data portion of a
+// DateStorageChannel message
+func (date *DateStorage) MarshalBinary() ([]byte, error) {
+	_, offset := date.Time.Zone()
+	year := date.Time.Year()
+	return []byte{
+		byte(date.Time.Second()),
+		byte(date.Time.Minute()),
+		byte(date.Time.Hour()),
+		byte(date.Time.Day()),
+		byte(date.Time.Month()),
+		byte(year >> 8),
+		byte(year),
+		// offset is sent in 15 minute increments
+		byte(int8(offset / (15 * 60))),
+	}, nil
+}
+
 // CourseData contains the Heading information sent in data channel
 // 56
 type CourseData struct {
@@ -347,12 +531,22 @@ func (*CourseData) Type() string { return "CourseData" }
 func (course *CourseData) UnmarshalBinary(buf []byte) error {
 	err := checkBufLen(buf, 8)
 	if err == nil {
-		course.Heading = Heading(computeGeo(buf[0:5])) * 0.00001
-		course.Accuracy = HeadingAccuracy(computeGeo(buf[5:8])) * 0.00001
+		course.Heading = Heading(computeGeo(buf[0:4])) * 0.00001
+		course.Accuracy = HeadingAccuracy(computeGeo(buf[4:8])) * 0.00001
 	}
 	return err
 }
 
+// DataChannel returns the data channel for CourseData (CourseDataChannel)
+func (*CourseData) DataChannel() Channel { return CourseDataChannel }
+
+// MarshalBinary encodes the CourseData into the data portion of a
+// CourseDataChannel message
+func (course *CourseData) MarshalBinary() ([]byte, error) {
+	buf := encodeGeo(float64(course.Heading) / 0.00001)
+	return append(buf, encodeGeo(float64(course.Accuracy)/0.00001)...), nil
+}
+
 // GPSAltitude is the altitude measurement reported by the GPS in the
 // data logger (data channel 57)
 type GPSAltitude struct {
@@ -378,8 +572,57 @@ func (altitude *GPSAltitude) UnmarshalBinary(buf []byte) error {
 	return err
 }
 
-func sampleFactory(channel Channel, buf []byte) (sample ParseableSample) {
+// DataChannel returns the data channel for a GPSAltitude (GPSAltitudeChannel)
+func (*GPSAltitude) DataChannel() Channel { return GPSAltitudeChannel }
+
+// MarshalBinary encodes the GPSAltitude into the data portion of a
+// GPSAltitudeChannel message
+func (altitude *GPSAltitude) MarshalBinary() ([]byte, error) {
+	buf := encodeUint32(uint32(altitude.Altitude))
+	return append(buf, encodeUint32(uint32(altitude.Accuracy))...), nil
+}
+
+// PreCalculatedDistance is the distance travelled since the start of the
+// run, as calculated by the data logger (data channel 78).  The distance
+// is an unsigned 32 bit value that is assumed to be in millimeters, since
+// the channel's resolution is not documented
+type PreCalculatedDistance struct {
+	// Distance is the distance travelled in meters
+	Distance Distance
+}
+
+// Type returns the Sample Type, in this case "PreCalculatedDistance"
+func (*PreCalculatedDistance) Type() string { return "PreCalculatedDistance" }
+
+// UnmarshalBinary parses the input byte buffer and assigns
+// the parsed values to the PreCalculatedDistance. BufError is returned
+// if the input buffer is too short to process
+func (pcd *PreCalculatedDistance) UnmarshalBinary(buf []byte) error {
+	err := checkBufLen(buf, 4)
+	if err == nil {
+		pcd.Distance = Distance(uint32(buf[0])<<24|uint32(buf[1])<<16|uint32(buf[2])<<8|uint32(buf[3])) * 0.001
+	}
+	return err
+}
+
+// DataChannel returns the data channel for a PreCalculatedDistance
+// (PreCalculatedDistanceDataChannel)
+func (*PreCalculatedDistance) DataChannel() Channel { return PreCalculatedDistanceDataChannel }
+
+// MarshalBinary encodes the PreCalculatedDistance into the data portion
+// of a PreCalculatedDistanceDataChannel message
+func (pcd *PreCalculatedDistance) MarshalBinary() ([]byte, error) {
+	return encodeUint32(uint32(math.Round(float64(pcd.Distance) / 0.001))), nil
+}
+
+// builtinFactory is the SampleFactory for all the data channels that are
+// decoded by this package
+func builtinFactory(channel Channel, buf []byte) (sample ParseableSample) {
 	switch {
+	case channel == RunInformationChannel:
+		sample = &RunInformation{}
+	case channel == NewSectorTime:
+		sample = &SectorTime{}
 	case channel == LapMarkerChannel:
 		sample = &LapMarker{}
 	case channel == LoggerStorageChannel:
@@ -406,6 +649,28 @@ func sampleFactory(channel Channel, buf []byte) (sample ParseableSample) {
 		sample = &CourseData{}
 	case channel == GPSAltitudeChannel:
 		sample = &GPSAltitude{}
+	case channel == PreCalculatedDistanceDataChannel:
+		sample = &PreCalculatedDistance{}
+	case ExtendedFrequencyChannel1 <= channel && channel <= ExtendedFrequencyChannel4:
+		sample = &ExtendedFrequencyInput{Channel: channel}
+	case channel == ExtendedRPMChannel:
+		sample = &RPM{}
+	case channel == YawRatesChannel:
+		sample = &YawRate{}
+	case channel == CalculatedYawChannel:
+		sample = &CalculatedYaw{}
+	case channel == PitchRateChannel:
+		sample = &PitchRate{}
+	case channel == PitchAngleChannel:
+		sample = &PitchAngle{}
+	case channel == RollRateChannel:
+		sample = &RollRate{}
+	case channel == RollAngleChannel:
+		sample = &RollAngle{}
+	case channel == GradientChannel:
+		sample = &Gradient{}
+	case channel == ZAccelerationChannel:
+		sample = &ZAcceleration{}
 	case channel == RunStatusChannel:
 		if len(buf) > 0 {
 			if buf[0] <= 4 {
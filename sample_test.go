@@ -0,0 +1,238 @@
+package dl
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"gonum.org/v1/gonum/unit"
+)
+
+func TestSectorTime(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected SectorTime
+	}{
+		{[]byte{0x02, 0x00, 0x00, 0x75, 0x30}, SectorTime{Sector: 2, Time: 30000}},
+		{[]byte{0x00, 0x00, 0x01, 0x5f, 0x90}, SectorTime{Sector: 0, Time: 90000}},
+		{[]byte{0x0f, 0x00, 0x36, 0xee, 0x80}, SectorTime{Sector: 15, Time: 3600000}},
+	}
+
+	for _, test := range tests {
+		sectorTime := &SectorTime{}
+		if err := sectorTime.UnmarshalBinary(test.data); err != nil {
+			t.Errorf("UnmarshalBinary(%v) returned %v", test.data, err)
+		} else if *sectorTime != test.expected {
+			t.Errorf("UnmarshalBinary(%v): expected %+v got %+v", test.data, test.expected, *sectorTime)
+		}
+	}
+
+	if err := (&SectorTime{}).UnmarshalBinary([]byte{0x02, 0x00, 0x00, 0x75}); err == nil {
+		t.Errorf("Expected an error for a short frame")
+	}
+}
+
+func TestComputeGeo(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected float64
+	}{
+		{[]byte{0x00, 0x00, 0x00, 0x00}, 0},
+		{[]byte{0x00, 0x00, 0x00, 0x01}, 1},
+		{[]byte{0xff, 0xff, 0xff, 0xff}, -1},
+		{[]byte{0x7f, 0xff, 0xff, 0xff}, math.MaxInt32},
+		{[]byte{0x80, 0x00, 0x00, 0x00}, math.MinInt32},
+		{[]byte{0x17, 0xe9, 0x8a, 0x3f}, 401181247},
+		{[]byte{0xd3, 0x4b, 0x7c, 0x15}, -750027755},
+	}
+
+	for _, test := range tests {
+		if value := computeGeo(test.data); value != test.expected {
+			t.Errorf("computeGeo(%v): expected %v got %v", test.data, test.expected, value)
+		}
+
+		if data := encodeGeo(test.expected); !bytes.Equal(data, test.data) {
+			t.Errorf("encodeGeo(%v): expected %v got %v", test.expected, test.data, data)
+		}
+	}
+}
+
+func TestGeoFrames(t *testing.T) {
+	tests := []struct {
+		channel  Channel
+		data     []byte
+		expected Sample
+	}{
+		{
+			GPSPositionChannel,
+			[]byte{0x17, 0xe9, 0x8a, 0x3f, 0xd3, 0x4b, 0x7c, 0x15, 0x00, 0x00, 0x01, 0xf4},
+			&GPSPosition{Latitude: 40.1181247, Longitude: -75.0027755, Accuracy: 500},
+		},
+		{
+			GPSPositionChannel,
+			[]byte{0xeb, 0xd0, 0x07, 0x3b, 0x5a, 0x20, 0xb5, 0x1b, 0x00, 0x00, 0x00, 0x0c},
+			&GPSPosition{Latitude: -33.8688197, Longitude: 151.2092955, Accuracy: 12},
+		},
+		{
+			CourseDataChannel,
+			[]byte{0x01, 0x9d, 0xb3, 0x99, 0x00, 0x00, 0xc3, 0x50},
+			&CourseData{Heading: 271.12345, Accuracy: 0.5},
+		},
+		{
+			LapMarkerChannel,
+			[]byte{0x03, 0xeb, 0xd0, 0x07, 0x3b, 0x5a, 0x20, 0xb5, 0x1b, 0x02, 0x25, 0x50, 0xff, 0, 0, 0, 0, 0, 0},
+			&LapMarker{Marker: 3, Latitude: -33.8688197, Longitude: 151.2092955, Heading: 359.99999},
+		},
+		{
+			LapMarkerChannel,
+			[]byte{0x01, 0x17, 0xe9, 0x8a, 0x3f, 0xd3, 0x4b, 0x7c, 0x15, 0x00, 0x89, 0x54, 0x40, 0, 0, 0, 0, 0, 0},
+			&LapMarker{Marker: 1, Latitude: 40.1181247, Longitude: -75.0027755, Heading: 90},
+		},
+		{
+			GradientChannel,
+			[]byte{0xff, 0xfe, 0x79, 0x60, 0x00, 0x00, 0x03, 0xe8},
+			&Gradient{Gradient: -1, Accuracy: 0.01},
+		},
+	}
+
+	for _, test := range tests {
+		sample, err := newMessage(test.channel, test.data).Decode()
+		if err != nil {
+			t.Errorf("%v: Decode() returned %v", test.channel, err)
+			continue
+		}
+
+		if !sameFields(sample, test.expected) {
+			t.Errorf("%v: expected %+v got %+v", test.channel, test.expected, sample)
+		}
+
+		data, _ := test.expected.(MarshalableSample).MarshalBinary()
+		if !bytes.Equal(data, test.data) {
+			t.Errorf("%v: expected %v got %v", test.channel, test.data, data)
+		}
+	}
+}
+
+// sameFields compares the exported fields of two samples, allowing for
+// rounding in floating point values
+func sameFields(a, b Sample) bool {
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	if va.Type() != vb.Type() {
+		return false
+	}
+
+	for i := 0; i < va.NumField(); i++ {
+		if va.Type().Field(i).PkgPath != "" {
+			continue
+		}
+
+		fa, fb := va.Field(i), vb.Field(i)
+		if ta, ok := fa.Interface().(time.Time); ok {
+			_, offsetA := ta.Zone()
+			_, offsetB := fb.Interface().(time.Time).Zone()
+			if !ta.Equal(fb.Interface().(time.Time)) || offsetA != offsetB {
+				return false
+			}
+			continue
+		}
+
+		switch fa.Kind() {
+		case reflect.Float32, reflect.Float64:
+			if math.Abs(fa.Float()-fb.Float()) > 1e-9*math.Max(1, math.Abs(fa.Float())) {
+				return false
+			}
+		default:
+			if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestSampleRoundTrip(t *testing.T) {
+	samples := []MarshalableSample{
+		&RunInformation{Data: []byte{0x0b, 0x03, 0x07, 0x12, 0x34, 0x00, 0x2a}},
+		&StartStopInfo{StartMethod: AutoStart, StopMethod: LowBatteryVoltage, PreTriggerLoopMethod: ButtonPress, PreTriggerTime: 1.5 * unit.Second, PostTriggerTime: 2 * unit.Second, AutoStartSource: ADC3, AutoStopSource: LateralGForce, LowestBuffer: 1234},
+		&TrackMarkerFailureMessage{Code: NoCard},
+		&Status{GPSDetected: true, GPS2Lock: true, RTKLock: true, INSConverged: true},
+		&SectorTime{Sector: 2, Time: 30000},
+		&LapMarker{Marker: 3, Latitude: -33.8688197, Longitude: 151.2092955, Heading: 359.99999},
+		&LoggerStorage{SerialNumber: 4242, SoftwareVersion: 7, BootloadVersion: 3},
+		&GPSTimeStorage{Time: 123456789},
+		&Accelerations{Lateral: 1.25, Longitudinal: -0.5},
+		&Timestamp{Timestamp: 123450},
+		&GPSPosition{Latitude: -33.8688197, Longitude: -75.0027755, Accuracy: 500},
+		&SpeedData{Speed: 45.67, Accuracy: 20},
+		&BeaconPulse{Data: 0x7f},
+		&FrequencyInput{Channel: FrequencyChannel3, Frequency: 1000},
+		&AnalogInput{Channel: AnalogChannel12, Voltage: 4321},
+		&DateStorage{Time: time.Date(2018, 6, 1, 12, 30, 45, 0, zoneLookup(-4*3600))},
+		&CourseData{Heading: 271.12345, Accuracy: 0.5},
+		&GPSAltitude{Altitude: 123456, Accuracy: 300},
+		&PreCalculatedDistance{Distance: 1234.567},
+		&ExtendedFrequencyInput{Channel: ExtendedFrequencyChannel2, Frequency: 60},
+		&RPM{Speed: 6000},
+		&YawRate{Rate: -12.34},
+		&CalculatedYaw{Yaw: 359.99},
+		&PitchRate{Rate: -1.234},
+		&PitchAngle{Angle: 2.5},
+		&RollRate{Rate: 10.125},
+		&RollAngle{Angle: -3.75},
+		&Gradient{Gradient: -1.23456, Accuracy: 0.01},
+		&ZAcceleration{Vertical: -1.5},
+	}
+
+	for _, sample := range samples {
+		data, err := sample.MarshalBinary()
+		if err != nil {
+			t.Errorf("%T: MarshalBinary() returned %v", sample, err)
+			continue
+		}
+
+		decoded, err := newMessage(sample.DataChannel(), data).Decode()
+		if err != nil {
+			t.Errorf("%T: Decode() returned %v", sample, err)
+			continue
+		}
+
+		if !sameFields(sample, decoded) {
+			t.Errorf("%T: expected %+v got %+v", sample, sample, decoded)
+		}
+	}
+}
+
+func TestPreCalculatedDistance(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected Distance
+	}{
+		{[]byte{0x00, 0x00, 0x00, 0x00}, 0},
+		{[]byte{0x00, 0x00, 0x00, 0x01}, 0.001},
+		{[]byte{0x00, 0x00, 0x03, 0xe8}, 1},
+		{[]byte{0x00, 0x12, 0xd6, 0x87}, 1234.567},
+		{[]byte{0xff, 0xff, 0xff, 0xff}, 4294967.295},
+	}
+
+	for _, test := range tests {
+		pcd := &PreCalculatedDistance{}
+		if err := pcd.UnmarshalBinary(test.data); err != nil {
+			t.Errorf("%v: UnmarshalBinary() returned %v", test.data, err)
+			continue
+		}
+
+		if math.Abs(float64(pcd.Distance-test.expected)) > 1e-9 {
+			t.Errorf("%v: expected %v got %v", test.data, test.expected, pcd.Distance)
+		}
+
+		if data, _ := pcd.MarshalBinary(); !bytes.Equal(data, test.data) {
+			t.Errorf("%v: expected %v got %v", test.expected, test.data, data)
+		}
+	}
+
+	if err := (&PreCalculatedDistance{}).UnmarshalBinary([]byte{0x00, 0x00, 0x03}); err == nil {
+		t.Errorf("expected an error for a short buffer")
+	}
+}
@@ -0,0 +1,73 @@
+//go:build linux
+// +build linux
+
+package dl
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+var baudRates = map[int]uint32{
+	1200:   unix.B1200,
+	2400:   unix.B2400,
+	4800:   unix.B4800,
+	9600:   unix.B9600,
+	19200:  unix.B19200,
+	38400:  unix.B38400,
+	57600:  unix.B57600,
+	115200: unix.B115200,
+	230400: unix.B230400,
+	460800: unix.B460800,
+	921600: unix.B921600,
+}
+
+// openSerial opens a serial device and configures it for raw
+// 8N1 communication at the given baud rate
+func openSerial(device string, baud int) (*os.File, error) {
+	rate, found := baudRates[baud]
+	if !found {
+		return nil, fmt.Errorf("Unsupported baud rate %d", baud)
+	}
+
+	file, err := os.OpenFile(device, os.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// the raw connection is used (rather than file.Fd()) so that the file
+	// remains non-blocking and reads are interrupted when it is closed
+	conn, err := file.SyscallConn()
+	if err == nil {
+		cerr := conn.Control(func(fd uintptr) {
+			var termios *unix.Termios
+			termios, err = unix.IoctlGetTermios(int(fd), unix.TCGETS)
+			if err != nil {
+				return
+			}
+
+			termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON | unix.IXOFF
+			termios.Oflag &^= unix.OPOST
+			termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+			termios.Cflag &^= unix.CSIZE | unix.PARENB | unix.CSTOPB | unix.CBAUD
+			termios.Cflag |= unix.CS8 | unix.CREAD | unix.CLOCAL | rate
+			termios.Ispeed = rate
+			termios.Ospeed = rate
+			termios.Cc[unix.VMIN] = 1
+			termios.Cc[unix.VTIME] = 0
+			err = unix.IoctlSetTermios(int(fd), unix.TCSETS, termios)
+		})
+
+		if err == nil {
+			err = cerr
+		}
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package dl
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// openSerial is not supported on this platform
+func openSerial(device string, baud int) (*os.File, error) {
+	return nil, fmt.Errorf("Serial ports are not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,209 @@
+package dl
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// ChannelSummary contains the statistics of one input channel or
+// measurement over the session
+type ChannelSummary struct {
+	// Unit is the unit the values are measured in
+	Unit string
+
+	// Min is the lowest value recorded
+	Min float64
+
+	// Max is the highest value recorded
+	Max float64
+
+	// Mean is the average of the recorded values
+	Mean float64
+
+	// Samples is the number of Epochs the channel was recorded in
+	Samples int
+}
+
+func (cs *ChannelSummary) add(value float64) {
+	if cs.Samples == 0 || value < cs.Min {
+		cs.Min = value
+	}
+
+	if cs.Samples == 0 || value > cs.Max {
+		cs.Max = value
+	}
+	cs.Samples++
+	cs.Mean += (value - cs.Mean) / float64(cs.Samples)
+}
+
+// SessionSummary describes a complete logging session.  It is emitted by
+// the SessionSummaryAnalyzer once the end of the input has been reached.
+// Durations and lap times are in milliseconds
+type SessionSummary struct {
+	// Logger is the data logger information, if the data logger reported it
+	Logger *LoggerStorage
+
+	// RunInformation is the run header, if the data logger reported it
+	RunInformation *RunInformation
+
+	// StartStopInfo indicates how the session was started and stopped
+	StartStopInfo StartStopInfo
+
+	// Start is the wall clock time at the beginning of the session.  Start
+	// is the zero time if the data logger did not report the date and time
+	Start time.Time
+
+	// Duration is the length of the session
+	Duration TimeOffset
+
+	// Laps is the number of complete laps.  Laps are only counted if the
+	// session summary follows a SectorAnalyzer
+	Laps int
+
+	// BestLap is the number of the fastest lap
+	BestLap int
+
+	// BestLapTime is the time of the fastest lap
+	BestLapTime TimeOffset
+
+	// AverageLapTime is the average time of all the laps
+	AverageLapTime TimeOffset
+
+	// LapTimeDeviation is the standard deviation of the lap times.  Lower
+	// values indicate more consistent laps
+	LapTimeDeviation TimeOffset
+
+	// TopSpeed is the highest speed recorded
+	TopSpeed Speed
+
+	// MaxLateralAcceleration is the highest lateral acceleration recorded
+	// in either direction
+	MaxLateralAcceleration Acceleration
+
+	// MaxAcceleration is the highest positive longitudinal acceleration
+	MaxAcceleration Acceleration
+
+	// MaxBraking is the highest negative longitudinal acceleration,
+	// reported as a positive value
+	MaxBraking Acceleration
+
+	// MaxVectorAcceleration is the highest combined acceleration recorded
+	MaxVectorAcceleration Acceleration
+
+	// Channels are the statistics of every analog input, frequency input
+	// and measurement recorded in the session, keyed by channel or
+	// measurement name
+	Channels map[string]*ChannelSummary
+}
+
+// Type returns the Sample Type, in this case "SessionSummary"
+func (*SessionSummary) Type() string { return "SessionSummary" }
+
+// SessionSummaryAnalyzer accumulates the statistics of a session and emits
+// a single SessionSummary once the input is closed.  The analyzer expects
+// Epochs and StartStopInfo samples produced by a SampleDemuxer and, to
+// summarize lap times, Laps produced by a SectorAnalyzer.  All input
+// samples are passed to the output
+type SessionSummaryAnalyzer struct {
+}
+
+// NewSessionSummaryAnalyzer returns a new SessionSummaryAnalyzer
+func NewSessionSummaryAnalyzer() *SessionSummaryAnalyzer { return &SessionSummaryAnalyzer{} }
+
+// add records a value of the named channel.  Values that are not finite
+// cannot be represented in JSON and are not counted
+func (summary *SessionSummary) add(name, unit string, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+
+	cs, found := summary.Channels[name]
+	if !found {
+		cs = &ChannelSummary{Unit: unit}
+		summary.Channels[name] = cs
+	}
+	cs.add(value)
+}
+
+func (summary *SessionSummary) addEpoch(epoch *Epoch) {
+	summary.TopSpeed = Speed(math.Max(float64(summary.TopSpeed), float64(epoch.Speed)))
+	summary.MaxLateralAcceleration = Acceleration(math.Max(float64(summary.MaxLateralAcceleration), math.Abs(float64(epoch.LateralAcceleration))))
+	summary.MaxAcceleration = Acceleration(math.Max(float64(summary.MaxAcceleration), float64(epoch.LongitudinalAcceleration)))
+	summary.MaxBraking = Acceleration(math.Max(float64(summary.MaxBraking), -float64(epoch.LongitudinalAcceleration)))
+	summary.MaxVectorAcceleration = Acceleration(math.Max(float64(summary.MaxVectorAcceleration), float64(epoch.VectorAcceleration)))
+
+	for channel, voltage := range epoch.AnalogInputs {
+		summary.add(channel.String(), "mV", float64(voltage))
+	}
+
+	for channel, frequency := range epoch.FrequencyInputs {
+		summary.add(channel.String(), "hz", float64(frequency))
+	}
+
+	for name, measurement := range epoch.Measurements {
+		summary.add(name, measurement.Unit, measurement.Value)
+	}
+}
+
+func (summary *SessionSummary) addLaps(laps []*Lap) {
+	summary.Laps = len(laps)
+	if len(laps) == 0 {
+		return
+	}
+
+	total := 0.0
+	for _, lap := range laps {
+		if summary.BestLap == 0 || lap.Elapsed() < summary.BestLapTime {
+			summary.BestLap = lap.Number
+			summary.BestLapTime = lap.Elapsed()
+		}
+		total += float64(lap.Elapsed())
+	}
+	average := total / float64(len(laps))
+
+	variance := 0.0
+	for _, lap := range laps {
+		variance += math.Pow(float64(lap.Elapsed())-average, 2)
+	}
+	summary.AverageLapTime = TimeOffset(math.Round(average))
+	summary.LapTimeDeviation = TimeOffset(math.Round(math.Sqrt(variance / float64(len(laps)))))
+}
+
+// Process will start the session summary loop.  This should usually be run
+// in a go routine
+func (ssa *SessionSummaryAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	summary := &SessionSummary{Channels: make(map[string]*ChannelSummary)}
+	var laps []*Lap
+	var first *Epoch
+
+	for sample := range input {
+		switch v := sample.(type) {
+		case *LoggerStorage:
+			summary.Logger = v
+		case *RunInformation:
+			summary.RunInformation = v
+		case *StartStopInfo:
+			summary.StartStopInfo = *v
+		case *Lap:
+			laps = append(laps, v)
+		case *Epoch:
+			if first == nil {
+				first = v
+			}
+
+			if summary.Start.IsZero() && !v.Time.IsZero() {
+				summary.Start = v.Time.Add(-time.Duration(v.Stop-first.Stop) * time.Millisecond)
+			}
+			summary.Duration = v.Stop - first.Stop
+			summary.addEpoch(v)
+		}
+
+		if err := send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+
+	summary.addLaps(laps)
+	return send(ctx, output, summary)
+}
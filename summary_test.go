@@ -0,0 +1,158 @@
+package dl
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func summarize(t *testing.T, samples []Sample, analyzers ...Analyzer) *SessionSummary {
+	t.Helper()
+	output := runAnalyzers(t, samples, append(analyzers, NewSessionSummaryAnalyzer())...)
+	if len(output) == 0 {
+		t.Fatalf("expected a SessionSummary got no output")
+	}
+
+	summary, ok := output[len(output)-1].(*SessionSummary)
+	if !ok {
+		t.Fatalf("expected the last sample to be a *SessionSummary got %T", output[len(output)-1])
+	}
+	return summary
+}
+
+func TestSessionSummary(t *testing.T) {
+	logger := &LoggerStorage{SerialNumber: 4242, SoftwareVersion: 7, BootloadVersion: 3}
+	runInfo := &RunInformation{Data: []byte{0x0b, 0x03, 0x07, 0x12, 0x34, 0x00, 0x2a}}
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	samples := []Sample{
+		logger,
+		runInfo,
+		&Epoch{
+			Stop: 100, Speed: 10, LateralAcceleration: -1.5, LongitudinalAcceleration: 0.5, VectorAcceleration: 1.6,
+			AnalogInputs: map[Channel]Voltage{AnalogChannel1: 1000},
+			Measurements: map[string]Measurement{"oil": {Unit: "C", Value: 80}},
+		},
+		&Epoch{
+			Stop: 200, Time: start, Speed: 30, LateralAcceleration: 1, LongitudinalAcceleration: -0.8, VectorAcceleration: 1.3,
+			AnalogInputs:    map[Channel]Voltage{AnalogChannel1: 2000},
+			FrequencyInputs: map[Channel]Frequency{FrequencyChannel1: 50},
+			Measurements:    map[string]Measurement{"oil": {Unit: "C", Value: 90}, "ratio": {Value: math.Inf(1)}},
+		},
+		&Epoch{
+			Stop: 1100, Speed: 20,
+			AnalogInputs: map[Channel]Voltage{AnalogChannel1: 3000},
+			Measurements: map[string]Measurement{"ratio": {Value: math.NaN()}},
+		},
+		&Lap{Number: 1, Start: 0, Stop: 60000},
+		&Lap{Number: 2, Start: 60000, Stop: 118000},
+		&Lap{Number: 3, Start: 118000, Stop: 180000},
+	}
+
+	summary := summarize(t, samples)
+	if summary.Logger != logger || summary.RunInformation != runInfo {
+		t.Errorf("expected the logger and run information to be recorded got %v and %v", summary.Logger, summary.RunInformation)
+	}
+
+	if expected := start.Add(-100 * time.Millisecond); !summary.Start.Equal(expected) {
+		t.Errorf("expected start %v got %v", expected, summary.Start)
+	}
+
+	tests := []struct {
+		name     string
+		expected interface{}
+		got      interface{}
+	}{
+		{"Duration", TimeOffset(1000), summary.Duration},
+		{"Laps", 3, summary.Laps},
+		{"BestLap", 2, summary.BestLap},
+		{"BestLapTime", TimeOffset(58000), summary.BestLapTime},
+		{"AverageLapTime", TimeOffset(60000), summary.AverageLapTime},
+		{"LapTimeDeviation", TimeOffset(1633), summary.LapTimeDeviation},
+		{"TopSpeed", Speed(30), summary.TopSpeed},
+		{"MaxLateralAcceleration", Acceleration(1.5), summary.MaxLateralAcceleration},
+		{"MaxAcceleration", Acceleration(0.5), summary.MaxAcceleration},
+		{"MaxBraking", Acceleration(0.8), summary.MaxBraking},
+		{"MaxVectorAcceleration", Acceleration(1.6), summary.MaxVectorAcceleration},
+	}
+
+	for _, test := range tests {
+		if test.expected != test.got {
+			t.Errorf("%s: expected %v got %v", test.name, test.expected, test.got)
+		}
+	}
+
+	expectedChannels := map[string]*ChannelSummary{
+		AnalogChannel1.String():    {Unit: "mV", Min: 1000, Max: 3000, Mean: 2000, Samples: 3},
+		FrequencyChannel1.String(): {Unit: "hz", Min: 50, Max: 50, Mean: 50, Samples: 1},
+		"oil":                      {Unit: "C", Min: 80, Max: 90, Mean: 85, Samples: 2},
+	}
+
+	if !reflect.DeepEqual(expectedChannels, summary.Channels) {
+		for name, cs := range summary.Channels {
+			t.Logf("%s: %+v", name, cs)
+		}
+		t.Errorf("expected %d channels got %d", len(expectedChannels), len(summary.Channels))
+	}
+}
+
+func TestSessionSummaryLateStartStopInfo(t *testing.T) {
+	samples := []Sample{
+		&StartStopInfo{StartMethod: AutoStart},
+		&SpeedData{Speed: 10},
+		&Timestamp{Timestamp: 100},
+		&SpeedData{Speed: 20},
+		&Timestamp{Timestamp: 200},
+		&StartStopInfo{StartMethod: AutoStart, StopMethod: LowBatteryVoltage},
+	}
+
+	summary := summarize(t, samples, &SampleDemuxer{})
+	if summary.StartStopInfo.StopMethod != LowBatteryVoltage {
+		t.Errorf("expected stop method %v got %v", LowBatteryVoltage, summary.StartStopInfo.StopMethod)
+	}
+
+	if summary.TopSpeed != 20 {
+		t.Errorf("expected top speed 20 got %v", summary.TopSpeed)
+	}
+}
+
+func TestSessionSummaryJSON(t *testing.T) {
+	// a frequency input without any pulses must not produce a value that
+	// cannot be encoded
+	freq, err := newMessage(FrequencyChannel1, []byte{0x00, 0x00, 0x00}).Decode()
+	if err != nil {
+		t.Fatalf("Decode() returned %v", err)
+	}
+
+	samples := []Sample{
+		&LoggerStorage{SerialNumber: 4242, SoftwareVersion: 7, BootloadVersion: 3},
+		&RunInformation{Data: []byte{0x0b, 0x03, 0x07, 0x12, 0x34, 0x00, 0x2a}},
+		&StartStopInfo{StartMethod: AutoStart, StopMethod: LowBatteryVoltage, PreTriggerTime: 1500},
+		&DateStorage{Time: time.Date(2018, 6, 1, 12, 30, 45, 0, time.UTC)},
+		&AnalogInput{Channel: AnalogChannel1, Voltage: 1234},
+		freq,
+		&Timestamp{Timestamp: 100},
+		&Timestamp{Timestamp: 200},
+		&Lap{Number: 1, Start: 100, Stop: 200},
+	}
+
+	summary := summarize(t, samples, &SampleDemuxer{})
+	buf, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("Marshal() returned %v", err)
+	}
+
+	decoded := &SessionSummary{}
+	if err := json.Unmarshal(buf, decoded); err != nil {
+		t.Fatalf("Unmarshal() returned %v", err)
+	}
+
+	if !reflect.DeepEqual(summary, decoded) {
+		t.Errorf("expected %+v got %+v", summary, decoded)
+	}
+
+	if cs := decoded.Channels[FrequencyChannel1.String()]; cs == nil || cs.Max != 0 {
+		t.Errorf("expected the frequency input to be recorded as 0 hz got %+v", cs)
+	}
+}
@@ -0,0 +1,91 @@
+package dl
+
+import (
+	"context"
+	"sort"
+)
+
+// lapProfile is the elapsed lap time at each point along a lap
+type lapProfile struct {
+	distances []float64
+	times     []TimeOffset
+}
+
+func newLapProfile(lap *Lap) *lapProfile {
+	profile := &lapProfile{
+		distances: []float64{0},
+		times:     []TimeOffset{0},
+	}
+
+	for _, epoch := range lap.Epochs {
+		profile.distances = append(profile.distances, float64(epoch.LapDistance))
+		profile.times = append(profile.times, epoch.Stop-lap.Start)
+	}
+	return profile
+}
+
+// timeAt returns the elapsed lap time at the given distance, interpolating
+// between the recorded points
+func (lp *lapProfile) timeAt(distance float64) TimeOffset {
+	i := sort.SearchFloat64s(lp.distances, distance)
+	if i == 0 {
+		return lp.times[0]
+	} else if i == len(lp.distances) {
+		return lp.times[len(lp.times)-1]
+	}
+
+	d0, d1 := lp.distances[i-1], lp.distances[i]
+	t0, t1 := lp.times[i-1], lp.times[i]
+	if d1 == d0 {
+		return t1
+	}
+	return t0 + TimeOffset(float64(t1-t0)*(distance-d0)/(d1-d0))
+}
+
+// TimeSlipAnalyzer computes the TimeSlip of each Epoch by comparing the
+// elapsed lap time with the time the reference lap took to travel the same
+// distance.  The analyzer must follow a SectorAnalyzer in the processing
+// chain
+type TimeSlipAnalyzer struct {
+	reference *Lap
+}
+
+// NewTimeSlipAnalyzer returns a TimeSlipAnalyzer that uses the fastest lap
+// of the session (so far) as the reference lap
+func NewTimeSlipAnalyzer() *TimeSlipAnalyzer { return &TimeSlipAnalyzer{} }
+
+// Reference sets a fixed reference lap, such as a lap loaded from another
+// run file, to compare every lap against
+func (tsa *TimeSlipAnalyzer) Reference(lap *Lap) *TimeSlipAnalyzer {
+	tsa.reference = lap
+	return tsa
+}
+
+// Process will start the time slip loop.  This should usually be run in
+// a go routine
+func (tsa *TimeSlipAnalyzer) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	var profile *lapProfile
+	var best *Lap
+	if tsa.reference != nil {
+		profile = newLapProfile(tsa.reference)
+	}
+
+	for sample := range input {
+		switch v := sample.(type) {
+		case *Lap:
+			if tsa.reference == nil && (best == nil || v.Elapsed() < best.Elapsed()) {
+				best = v
+				profile = newLapProfile(v)
+			}
+		case *Epoch:
+			if v.Lap > 0 && profile != nil {
+				v.TimeSlip = v.LapTime - profile.timeAt(float64(v.LapDistance))
+			}
+		}
+
+		if err := send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
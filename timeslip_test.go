@@ -0,0 +1,81 @@
+package dl
+
+import (
+	"testing"
+)
+
+// constantLap drives a lap of the given length (in meters) at a constant
+// speed (in m/s), recording an Epoch every 100 ms
+func constantLap(number int, start TimeOffset, speed float64, length Distance) *Lap {
+	lap := &Lap{Number: number, Start: start}
+	for t := TimeOffset(100); ; t += 100 {
+		distance := Distance(speed * float64(t) / 1000)
+		if distance > length {
+			break
+		}
+		lap.Stop = start + t
+		lap.Epochs = append(lap.Epochs, &Epoch{Start: lap.Stop - 100, Stop: lap.Stop, Lap: number, LapTime: t, LapDistance: distance})
+	}
+	return lap
+}
+
+// lapSamples returns the Epochs of the lap followed by the Lap itself, the
+// order they are emitted by a SectorAnalyzer
+func lapSamples(laps ...*Lap) []Sample {
+	var samples []Sample
+	for _, lap := range laps {
+		for _, epoch := range lap.Epochs {
+			samples = append(samples, epoch)
+		}
+		samples = append(samples, lap)
+	}
+	return samples
+}
+
+func TestTimeSlipReference(t *testing.T) {
+	reference := constantLap(1, 0, 20, 200)
+	lap := constantLap(1, 0, 25, 200)
+
+	runAnalyzers(t, lapSamples(lap), NewTimeSlipAnalyzer().Reference(reference))
+
+	// 25 m/s gains 0.25 s for every second driven compared to 20 m/s
+	for _, epoch := range lap.Epochs {
+		if expected := -epoch.LapTime / 4; epoch.TimeSlip != expected {
+			t.Errorf("At %v: expected time slip %v got %v", epoch.LapDistance, expected, epoch.TimeSlip)
+		}
+	}
+}
+
+func TestTimeSlipSessionBest(t *testing.T) {
+	laps := []*Lap{
+		constantLap(1, 0, 20, 200),
+		constantLap(2, 10000, 25, 200),
+		constantLap(3, 18000, 20, 200),
+		constantLap(4, 28000, 22, 200),
+	}
+
+	runAnalyzers(t, lapSamples(laps...), NewTimeSlipAnalyzer())
+
+	tests := []struct {
+		lap      *Lap
+		expected func(lapTime TimeOffset) TimeOffset
+	}{
+		// there is no reference until the first lap is complete
+		{laps[0], func(TimeOffset) TimeOffset { return 0 }},
+		// compared to lap 1 at 20 m/s
+		{laps[1], func(lapTime TimeOffset) TimeOffset { return -lapTime / 4 }},
+		// lap 2 is the new session best at 25 m/s
+		{laps[2], func(lapTime TimeOffset) TimeOffset { return lapTime / 5 }},
+		// lap 3 was slower, so lap 2 is still the reference
+		{laps[3], func(lapTime TimeOffset) TimeOffset { return lapTime - TimeOffset(float64(lapTime)*22/25) }},
+	}
+
+	for _, test := range tests {
+		for _, epoch := range test.lap.Epochs {
+			expected := test.expected(epoch.LapTime)
+			if diff := epoch.TimeSlip - expected; diff < -1 || diff > 1 {
+				t.Errorf("Lap %d at %v: expected time slip %v got %v", test.lap.Number, epoch.LapDistance, expected, epoch.TimeSlip)
+			}
+		}
+	}
+}
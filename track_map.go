@@ -1,17 +1,224 @@
 package dl
 
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Track directions
+const (
+	Clockwise        = "clockwise"
+	CounterClockwise = "counterclockwise"
+)
+
+// WayPoint is a point on the centerline of a track
 type WayPoint struct {
-	Latitude  Coordinate
-	Longitude Coordinate
+	Latitude  Coordinate `json:"latitude"`
+	Longitude Coordinate `json:"longitude"`
 }
 
+// TrackLine is a timing line, such as the start/finish line, that crosses
+// the track at the given position.  Heading is the direction of travel
+// across the line
+type TrackLine struct {
+	Latitude  Coordinate `json:"latitude"`
+	Longitude Coordinate `json:"longitude"`
+	Heading   Heading    `json:"heading"`
+}
+
+// TrackMap describes a track.  The WayPoints of the centerline begin at
+// the start/finish line and are in the order they are driven
 type TrackMap struct {
-	Name      string
-	WayPoints []WayPoint
+	Name      string     `json:"name"`
+	Direction string     `json:"direction,omitempty"`
+	WayPoints []WayPoint `json:"waypoints"`
+
+	// StartFinish is the start/finish line
+	StartFinish *TrackLine `json:"start_finish,omitempty"`
+
+	// Sectors are the sector lines, in the order they are driven
+	Sectors []TrackLine `json:"sectors,omitempty"`
 }
 
+// Tracks is the track database, keyed by track name
 var Tracks map[string]*TrackMap
 
 func init() {
 	Tracks = make(map[string]*TrackMap)
 }
+
+// SectorInfo returns the lap markers for the start/finish line, as marker
+// 0, followed by the sector lines.  SectorInfo returns nil if the track
+// has no start/finish line
+func (tm *TrackMap) SectorInfo() *SectorInfo {
+	if tm.StartFinish == nil {
+		return nil
+	}
+
+	sectorInfo := &SectorInfo{}
+	for i, line := range append([]TrackLine{*tm.StartFinish}, tm.Sectors...) {
+		sectorInfo.AddMarker(&LapMarker{Marker: i, Latitude: line.Latitude, Longitude: line.Longitude, Heading: line.Heading})
+	}
+	return sectorInfo
+}
+
+// ReadTrack reads a JSON track definition from the reader.  Other formats,
+// such as YAML, are not supported.  A ParseError is returned if the track
+// has no name, has fewer than two way points, has an unknown direction or
+// if the way points do not start within DefaultMarkerWidth of the
+// start/finish line
+func ReadTrack(reader io.Reader) (*TrackMap, error) {
+	track := &TrackMap{}
+	if err := json.NewDecoder(reader).Decode(track); err != nil {
+		return nil, err
+	}
+
+	if track.Name == "" {
+		return nil, newParseError("Track has no name")
+	}
+
+	if len(track.WayPoints) < 2 {
+		return nil, newParseError(fmt.Sprintf("Track %q needs at least two way points", track.Name))
+	}
+
+	if track.Direction != "" && track.Direction != Clockwise && track.Direction != CounterClockwise {
+		return nil, newParseError(fmt.Sprintf("Track %q has unknown direction %q", track.Name, track.Direction))
+	}
+
+	if line := track.StartFinish; line != nil {
+		first := track.WayPoints[0]
+		if offset := greatCircle(line.Latitude, line.Longitude, first.Latitude, first.Longitude); offset > DefaultMarkerWidth {
+			return nil, newParseError(fmt.Sprintf("Track %q way points start %.1fm from the start/finish line", track.Name, offset))
+		}
+	}
+	return track, nil
+}
+
+// LoadTrack reads a JSON track definition from the named file
+func LoadTrack(filename string) (*TrackMap, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	track, err := ReadTrack(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return track, nil
+}
+
+// LoadTracks reads every track definition (files ending in .json) in the
+// directory and adds them to Tracks.  Tracks with the same name as a track
+// already in the database replace the existing track.  If any of the files
+// can not be loaded the error is returned and Tracks is left unchanged
+func LoadTracks(dir string) error {
+	filenames, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	tracks := make(map[string]*TrackMap)
+	for _, filename := range filenames {
+		track, err := LoadTrack(filename)
+		if err != nil {
+			return err
+		}
+		tracks[track.Name] = track
+	}
+
+	for name, track := range tracks {
+		Tracks[name] = track
+	}
+	return nil
+}
+
+// WriteTrack writes the JSON track definition to the writer
+func WriteTrack(writer io.Writer, track *TrackMap) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(track)
+}
+
+// SaveTrack writes the JSON track definition to the named file.  The
+// track can be read back with LoadTrack
+func SaveTrack(filename string, track *TrackMap) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	err = WriteTrack(file, track)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// NewLapTrackMap returns a TrackMap that follows the positions recorded
+// during the lap
+func NewLapTrackMap(lap *Lap) *TrackMap {
+	tm := &TrackMap{}
+	for _, epoch := range lap.Epochs {
+		if hasPosition(epoch) {
+			tm.WayPoints = append(tm.WayPoints, WayPoint{Latitude: epoch.Latitude, Longitude: epoch.Longitude})
+		}
+	}
+	return tm
+}
+
+// Length returns the distance from the first to the last way point
+func (tm *TrackMap) Length() Distance {
+	length := 0.0
+	for i := 1; i < len(tm.WayPoints); i++ {
+		start, end := tm.WayPoints[i-1], tm.WayPoints[i]
+		length += greatCircle(start.Latitude, start.Longitude, end.Latitude, end.Longitude)
+	}
+	return Distance(length)
+}
+
+// project returns the distance along the track map, from the first way
+// point, of the point on the map closest to the given position along with
+// the length of the map
+func (tm *TrackMap) project(latitude, longitude Coordinate) (distance, length float64) {
+	closest := math.Inf(1)
+	for i := 1; i < len(tm.WayPoints); i++ {
+		from, to := tm.WayPoints[i-1], tm.WayPoints[i]
+		segmentLength := greatCircle(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+		x1, y1 := localOffset(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+		x, y := localOffset(from.Latitude, from.Longitude, latitude, longitude)
+
+		fraction := 0.0
+		if segment := x1*x1 + y1*y1; segment > 0 {
+			fraction = math.Max(0, math.Min(1, (x*x1+y*y1)/segment))
+		}
+
+		if offset := math.Hypot(x-fraction*x1, y-fraction*y1); offset < closest {
+			closest = offset
+			distance = length + fraction*segmentLength
+		}
+		length += segmentLength
+	}
+	return distance, length
+}
+
+// Project returns the distance along the track map, from the start/finish
+// line, of the point on the map closest to the given position.  Points
+// before the start/finish line are measured around the rest of the map.  If
+// the map has no start/finish line the distance is measured from the first
+// way point
+func (tm *TrackMap) Project(latitude, longitude Coordinate) Distance {
+	distance, length := tm.project(latitude, longitude)
+	if tm.StartFinish != nil {
+		origin, _ := tm.project(tm.StartFinish.Latitude, tm.StartFinish.Longitude)
+		if distance -= origin; distance < 0 {
+			distance += length
+		}
+	}
+	return Distance(distance)
+}
@@ -0,0 +1,92 @@
+package dl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveTrack(t *testing.T) {
+	track := circleTrackMap(0)
+	track.Direction = Clockwise
+	lat, lon := position(circleRadius, 0)
+	track.Sectors = []TrackLine{{Latitude: lat, Longitude: lon, Heading: 180}}
+
+	filename := filepath.Join(t.TempDir(), "circle.json")
+	if err := SaveTrack(filename, track); err != nil {
+		t.Fatalf("SaveTrack() returned %v", err)
+	}
+
+	loaded, err := LoadTrack(filename)
+	if err != nil {
+		t.Fatalf("LoadTrack() returned %v", err)
+	}
+
+	if !reflect.DeepEqual(loaded, track) {
+		t.Errorf("expected %+v got %+v", track, loaded)
+	}
+}
+
+func TestReadTrack(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   bool
+	}{
+		{"valid", `{"name": "t", "waypoints": [{"latitude": 40, "longitude": -75}, {"latitude": 40.001, "longitude": -75}], "start_finish": {"latitude": 40.0001, "longitude": -75, "heading": 0}}`, false},
+		{"no start/finish", `{"name": "t", "waypoints": [{"latitude": 40, "longitude": -75}, {"latitude": 40.001, "longitude": -75}]}`, false},
+		{"way points after start/finish", `{"name": "t", "waypoints": [{"latitude": 40, "longitude": -75}, {"latitude": 40.001, "longitude": -75}], "start_finish": {"latitude": 40.001, "longitude": -75, "heading": 0}}`, true},
+		{"no name", `{"waypoints": [{"latitude": 40, "longitude": -75}, {"latitude": 40.001, "longitude": -75}]}`, true},
+		{"one way point", `{"name": "t", "waypoints": [{"latitude": 40, "longitude": -75}]}`, true},
+		{"unknown direction", `{"name": "t", "direction": "sideways", "waypoints": [{"latitude": 40, "longitude": -75}, {"latitude": 40.001, "longitude": -75}]}`, true},
+	}
+
+	for _, test := range tests {
+		_, err := ReadTrack(strings.NewReader(test.input))
+		var parseErr *ParseError
+		if test.err && !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected a ParseError got %v", test.name, err)
+		} else if !test.err && err != nil {
+			t.Errorf("%s: ReadTrack() returned %v", test.name, err)
+		}
+	}
+}
+
+func TestLoadTracks(t *testing.T) {
+	saved := Tracks
+	defer func() { Tracks = saved }()
+	Tracks = map[string]*TrackMap{"existing": {Name: "existing"}}
+
+	dir := t.TempDir()
+	for _, name := range []string{"first", "second"} {
+		track := circleTrackMap(0)
+		track.Name = name
+		if err := SaveTrack(filepath.Join(dir, name+".json"), track); err != nil {
+			t.Fatalf("SaveTrack() returned %v", err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"name": "broken"}`), 0644); err != nil {
+		t.Fatalf("WriteFile() returned %v", err)
+	}
+
+	if err := LoadTracks(dir); err == nil {
+		t.Errorf("expected an error for the broken track")
+	}
+
+	if len(Tracks) != 1 || Tracks["existing"] == nil {
+		t.Errorf("expected Tracks to be unchanged got %v", Tracks)
+	}
+
+	os.Remove(filepath.Join(dir, "broken.json"))
+	if err := LoadTracks(dir); err != nil {
+		t.Fatalf("LoadTracks() returned %v", err)
+	}
+
+	if len(Tracks) != 3 || Tracks["first"] == nil || Tracks["second"] == nil {
+		t.Errorf("expected the existing, first and second tracks got %v", Tracks)
+	}
+}
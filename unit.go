@@ -3,6 +3,7 @@ package dl
 
 import (
 	"fmt"
+	"math"
 	"time"
 )
 
@@ -33,12 +34,58 @@ func formatUnit(fs fmt.State, c rune, unit string, receiver, value interface{})
 	}
 }
 
+// computeGeo converts the first four bytes of buf from a signed 32 bit
+// big endian integer.  Coordinates, headings and their accuracies are all
+// encoded this way
 func computeGeo(buf []byte) float64 {
-	value := int64(buf[0]&0x7f)<<24 | int64(buf[1])<<16 | int64(buf[2])<<8 | int64(buf[1])
-	if buf[0]&0x80 == 0x80 {
-		value = value - 2147483648
+	return float64(int32(uint32(buf[0])<<24 | uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3])))
+}
+
+// encodeGeo is the inverse of computeGeo and encodes a value as
+// a signed 32 bit big endian integer
+func encodeGeo(value float64) []byte {
+	return encodeUint32(uint32(int32(math.Round(value))))
+}
+
+// computeSigned converts a big endian two's complement value of
+// len(buf) bytes
+func computeSigned(buf []byte) int64 {
+	value := int64(0)
+	for _, b := range buf {
+		value = value<<8 | int64(b)
+	}
+
+	bits := uint(len(buf) * 8)
+	if value&(1<<(bits-1)) != 0 {
+		value -= 1 << bits
+	}
+	return value
+}
+
+// encodeSigned is the inverse of computeSigned and encodes a value as a
+// big endian two's complement value of length bytes
+func encodeSigned(value float64, length int) []byte {
+	v := int64(math.Round(value))
+	buf := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		buf[i] = byte(v)
+		v >>= 8
 	}
-	return float64(value)
+	return buf
+}
+
+// undecoded returns a buffer of the given length holding the data a sample
+// was decoded from, so that bytes which are not decoded into any field are
+// written back unchanged.  Samples that were not decoded start with a zeroed
+// buffer
+func undecoded(raw []byte, length int) []byte {
+	buf := make([]byte, length)
+	copy(buf, raw)
+	return buf
+}
+
+func encodeUint32(value uint32) []byte {
+	return []byte{byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)}
 }
 
 func computeAcceleration(buf []byte) Acceleration {
@@ -49,6 +96,23 @@ func computeAcceleration(buf []byte) Acceleration {
 	return Acceleration(value)
 }
 
+// encodeAcceleration is the inverse of computeAcceleration
+func encodeAcceleration(acceleration Acceleration) []byte {
+	value := math.Abs(float64(acceleration))
+	whole := math.Floor(value)
+	fraction := math.Round((value - whole) * 0x100)
+	if fraction == 0x100 {
+		whole++
+		fraction = 0
+	}
+
+	buf := []byte{byte(whole) & 0x7f, byte(fraction)}
+	if !math.Signbit(float64(acceleration)) {
+		buf[0] |= 0x80
+	}
+	return buf
+}
+
 var timezones = make(map[int]*time.Location)
 
 func zoneLookup(offset int) (zone *time.Location) {
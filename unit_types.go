@@ -105,3 +105,27 @@ type AltitudeAccuracy int
 func (accuracy AltitudeAccuracy) Format(f fmt.State, c rune) {
 	formatUnit(f, c, "mm", accuracy, int(accuracy))
 }
+
+// AngularRate is the rate of rotation around one of the vehicle's axes. AngularRate is measured in degrees per second
+type AngularRate float64
+
+// Format satisfies interface fmt.Formatter
+func (rate AngularRate) Format(f fmt.State, c rune) { formatUnit(f, c, "°/s", rate, float64(rate)) }
+
+// EngineSpeed is the rotational speed of the engine. EngineSpeed is measured in revolutions per minute
+type EngineSpeed float64
+
+// Format satisfies interface fmt.Formatter
+func (speed EngineSpeed) Format(f fmt.State, c rune) { formatUnit(f, c, "rpm", speed, float64(speed)) }
+
+// Angle is the rotation around one of the vehicle's axes. Angle is measured in degrees
+type Angle float64
+
+// Format satisfies interface fmt.Formatter
+func (angle Angle) Format(f fmt.State, c rune) { formatUnit(f, c, "°", angle, float64(angle)) }
+
+// Distance is the distance travelled by the vehicle. Distance is measured in meters
+type Distance float64
+
+// Format satisfies interface fmt.Formatter
+func (dist Distance) Format(f fmt.State, c rune) { formatUnit(f, c, "m", dist, float64(dist)) }
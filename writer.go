@@ -0,0 +1,84 @@
+package dl
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// RunWriter encodes samples into data frames and writes them to
+// an underlying writer, producing a run file that can be read by a
+// RunReader
+type RunWriter struct {
+	writer *bufio.Writer
+}
+
+// NewRunWriter takes an io.Writer object and returns a RunWriter
+// ready to write messages to the underlying Writer
+func NewRunWriter(writer io.Writer) *RunWriter {
+	return &RunWriter{
+		writer: bufio.NewWriter(writer),
+	}
+}
+
+// frame returns the message that will be written for the given sample
+func frame(sample Sample) (*Message, error) {
+	switch v := sample.(type) {
+	case *Message:
+		return v, nil
+	case MarshalableSample:
+		data, err := v.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return newMessage(v.DataChannel(), data), nil
+	}
+	return nil, ErrNotMarshalable
+}
+
+// Write encodes a single sample and writes it to the underlying writer.
+// Messages are written exactly as they were received, any other sample
+// must implement MarshalableSample and is written with a newly computed
+// checksum.  Writes are buffered, so Flush must be called once all samples
+// have been written
+func (rw *RunWriter) Write(sample Sample) error {
+	message, err := frame(sample)
+	if err != nil {
+		return err
+	}
+
+	length, found := ChannelLength(message.Channel)
+	if !found {
+		return ErrUnknownLength
+	} else if length != message.Length() {
+		return newBufError(ErrUnexpectedInput, length-2, len(message.Data))
+	}
+
+	buf, err := message.MarshalBinary()
+	if err == nil {
+		_, err = rw.writer.Write(buf)
+	}
+	return err
+}
+
+// Flush writes any buffered data to the underlying writer
+func (rw *RunWriter) Flush() error {
+	return rw.writer.Flush()
+}
+
+// Process writes every sample that can be marshaled to the underlying
+// writer and passes all samples to the output.  Samples that cannot be
+// marshaled, such as Epochs, are skipped.  Processing stops at the first
+// write error
+func (rw *RunWriter) Process(ctx context.Context, input <-chan Sample, output chan<- Sample) error {
+	for sample := range input {
+		if err := rw.Write(sample); err != nil && err != ErrNotMarshalable {
+			return err
+		}
+
+		if err := send(ctx, output, sample); err != nil {
+			return err
+		}
+	}
+	return rw.Flush()
+}
@@ -0,0 +1,110 @@
+package dl
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// roundTripFrames are the data portion of a frame for every channel that
+// is decoded into a sample.  Bytes that are not decoded into any field are
+// deliberately non-zero
+var roundTripFrames = []struct {
+	channel Channel
+	data    []byte
+}{
+	{RunInformationChannel, []byte{0x0b, 0x03, 0x07, 0x12, 0x34, 0x00, 0x2a}},
+	{RunStatusChannel, []byte{0x01, 0x02, 0x01, 0x0a, 0x14, 0x0f, 0x10, 0x01, 0x02}},
+	{RunStatusChannel, []byte{0x04, 0x21, 0x01, 0x0a, 0x14, 0x0f, 0x10, 0x01, 0x02}},
+	{RunStatusChannel, []byte{0x05, 0x04, 0xde, 0xad, 0xbe, 0xef, 0x01, 0x02, 0x03}},
+	{RunStatusChannel, []byte{0x06, 0xa5, 0xde, 0xad, 0xbe, 0xef, 0x01, 0x02, 0x03}},
+	{NewSectorTime, []byte{0x02, 0x00, 0x00, 0x75, 0x30}},
+	{LapMarkerChannel, []byte{0x01, 0x18, 0x2b, 0x5c, 0x40, 0xd3, 0x4e, 0x0e, 0x80, 0x00, 0x89, 0x54, 0x40, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06}},
+	{LoggerStorageChannel, []byte{0x12, 0x34, 0x05, 0x02}},
+	{GPSTimeStorageChannel, []byte{0x0a, 0x1b, 0x2c, 0x3d}},
+	{AccelerationsChannel, []byte{0x81, 0x40, 0x00, 0x00}},
+	{TimestampChannel, []byte{0x01, 0x02, 0x03}},
+	{GPSPositionChannel, []byte{0x18, 0x2b, 0x5c, 0x40, 0xd3, 0x4e, 0x0e, 0x80, 0x00, 0x00, 0x01, 0xf4}},
+	{SpeedDataChannel, []byte{0x00, 0x00, 0x0f, 0xa0, 0x00, 0x00, 0x00, 0x32}},
+	{BeaconPulseChannel, []byte{0x7f}},
+	{FrequencyChannel2, []byte{0x00, 0x17, 0x70}},
+	{AnalogChannel3, []byte{0x10, 0xe1}},
+	{DateStorageChannel, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x07, 0xe2, 0xf0}},
+	{CourseDataChannel, []byte{0x01, 0x12, 0xa8, 0x80, 0x00, 0x00, 0x27, 0x10}},
+	{GPSAltitudeChannel, []byte{0x00, 0x01, 0x86, 0xa0, 0x00, 0x00, 0x01, 0x2c}},
+	{ExtendedFrequencyChannel1, []byte{0x00, 0x01, 0x86, 0xa0, 0x01, 0x02, 0x03, 0x04, 0x05}},
+	{ExtendedRPMChannel, []byte{0x00, 0x00, 0x3a, 0x98, 0x01, 0x02, 0x03, 0x04, 0x05}},
+	{PreCalculatedDistanceDataChannel, []byte{0x00, 0x01, 0xe2, 0x40}},
+	{YawRatesChannel, []byte{0xfc, 0x18}},
+	{CalculatedYawChannel, []byte{0x8c, 0x9f}},
+	{PitchRateChannel, []byte{0xff, 0xfc, 0x18}},
+	{PitchAngleChannel, []byte{0x00, 0x03, 0xe8}},
+	{RollRateChannel, []byte{0xff, 0x00, 0x00}},
+	{RollAngleChannel, []byte{0x7f, 0xff, 0xff}},
+	{GradientChannel, []byte{0xff, 0xfe, 0x79, 0x60, 0x00, 0x00, 0x03, 0xe8}},
+	{ZAccelerationChannel, []byte{0x00, 0x80}},
+}
+
+func TestRunWriterRoundTrip(t *testing.T) {
+	input := &bytes.Buffer{}
+	for _, frame := range roundTripFrames {
+		buf, _ := newMessage(frame.channel, frame.data).MarshalBinary()
+		input.Write(buf)
+	}
+
+	output := &bytes.Buffer{}
+	writer := NewRunWriter(output)
+	chain := NewProcessingChain(context.Background(), NewRunReader(bytes.NewReader(input.Bytes())))
+	chain.Append(&RunParser{}).Append(writer)
+
+	i := 0
+	for sample := range chain.Output() {
+		if _, ok := sample.(MarshalableSample); !ok {
+			t.Errorf("Frame %d (%v): expected a decoded sample got %T", i, roundTripFrames[i].channel, sample)
+		}
+		i++
+	}
+
+	if err := chain.Wait(); err != nil {
+		t.Fatalf("Wait() returned %v", err)
+	}
+
+	if i != len(roundTripFrames) {
+		t.Fatalf("Expected %d samples got %d", len(roundTripFrames), i)
+	}
+
+	if !bytes.Equal(input.Bytes(), output.Bytes()) {
+		for i := 0; i < input.Len() && i < output.Len(); i++ {
+			if input.Bytes()[i] != output.Bytes()[i] {
+				t.Fatalf("Output differs from the input at offset %d:\n%s\n%s", i, hexDump(input.Bytes()), hexDump(output.Bytes()))
+			}
+		}
+		t.Fatalf("Expected %d bytes got %d", input.Len(), output.Len())
+	}
+}
+
+func TestRunWriterMessages(t *testing.T) {
+	// messages are written as received, including a bad checksum
+	message := newMessage(AnalogChannel1, []byte{0x01, 0x02})
+	message.Checksum++
+
+	buf := &bytes.Buffer{}
+	writer := NewRunWriter(buf)
+	if err := writer.Write(message); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	writer.Flush()
+
+	expected := []byte{byte(AnalogChannel1), 0x01, 0x02, message.Checksum}
+	if !bytes.Equal(expected, buf.Bytes()) {
+		t.Errorf("Expected %v got %v", expected, buf.Bytes())
+	}
+
+	if err := writer.Write(&Epoch{}); err != ErrNotMarshalable {
+		t.Errorf("Expected ErrNotMarshalable got %v", err)
+	}
+
+	if err := writer.Write(newMessage(AnalogChannel1, []byte{0x01})); err == nil {
+		t.Errorf("Expected an error for a short message")
+	}
+}